@@ -0,0 +1,160 @@
+// Package mtls implements authentication interfaces using client certificates of mutual TLS.
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/pthethanh/micro/auth"
+	"github.com/pthethanh/micro/status"
+)
+
+type (
+	// Identity is identity of a peer, extracted from its verified client certificate.
+	Identity struct {
+		// CommonName is common name of the subject.
+		CommonName string
+		// DNSNames is DNS names of the subject alternative names.
+		DNSNames []string
+		// URIs is URIs of the subject alternative names, i.e: SPIFFE IDs.
+		URIs []string
+		// EmailAddresses is email addresses of the subject alternative names.
+		EmailAddresses []string
+		// Certificate is the leaf certificate of the peer.
+		Certificate *x509.Certificate
+	}
+
+	// Option is an option to configure the authenticator.
+	Option func(*authenticator)
+
+	authenticator struct {
+		allowed  map[string]bool
+		optional bool
+	}
+
+	identityKey struct{}
+)
+
+// Allow is an option to allow only the peers having one of the given names
+// as common name or subject alternative name (DNS, URI or email).
+// By default, all peers having a certificate verified by the client CAs are allowed.
+func Allow(names ...string) Option {
+	return func(a *authenticator) {
+		for _, name := range names {
+			a.allowed[name] = true
+		}
+	}
+}
+
+// Optional is an option to allow peers without client certificate, i.e: when the server
+// is configured to verify client certificates if given. The identity is not available
+// in the context of such requests.
+func Optional() Option {
+	return func(a *authenticator) {
+		a.optional = true
+	}
+}
+
+// Authenticator return an authenticator that authenticates the peers using their
+// verified client certificates and attaches their identity into the context.
+// The server must be configured with client CAs, see server.ClientCA.
+func Authenticator(opts ...Option) auth.AuthenticatorFunc {
+	a := &authenticator{
+		allowed: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a.authenticate
+}
+
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	cert, ok := peerCertificate(ctx)
+	if !ok {
+		if a.optional {
+			return ctx, nil
+		}
+		return ctx, status.Unauthenticated("mtls: missing verified client certificate")
+	}
+	id := newIdentity(cert)
+	if len(a.allowed) > 0 && !a.isAllowed(id) {
+		return ctx, status.PermissionDenied("mtls: peer %s is not allowed", id.CommonName)
+	}
+	return NewContext(ctx, id), nil
+}
+
+func (a *authenticator) isAllowed(id Identity) bool {
+	if a.allowed[id.CommonName] {
+		return true
+	}
+	for _, names := range [][]string{id.DNSNames, id.URIs, id.EmailAddresses} {
+		for _, name := range names {
+			if a.allowed[name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewContext return new context with the given identity.
+func NewContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext return identity of the peer from the context.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// NewPeerContext return new context of the HTTP request carrying its TLS information as peer,
+// so that the authenticator can be used for HTTP requests via auth.HTTPInterceptor.
+func NewPeerContext(r *http.Request) context.Context {
+	if r.TLS == nil {
+		return r.Context()
+	}
+	var addr net.Addr
+	if tcpAddr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		addr = tcpAddr
+	}
+	return peer.NewContext(r.Context(), &peer.Peer{
+		Addr: addr,
+		AuthInfo: credentials.TLSInfo{
+			State:          *r.TLS,
+			CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		},
+	})
+}
+
+// peerCertificate return the verified leaf certificate of the peer.
+func peerCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return info.State.VerifiedChains[0][0], true
+}
+
+func newIdentity(cert *x509.Certificate) Identity {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+	return Identity{
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		URIs:           uris,
+		EmailAddresses: cert.EmailAddresses,
+		Certificate:    cert,
+	}
+}
@@ -0,0 +1,53 @@
+package mtls_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/pthethanh/micro/auth/mtls"
+	"github.com/pthethanh/micro/status"
+)
+
+func TestAuthenticator(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/orders")
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "orders"},
+		DNSNames: []string{"orders.svc"},
+		URIs:     []*url.URL{spiffe},
+	}
+	withCert := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+	cases := []struct {
+		name string
+		ctx  context.Context
+		opts []mtls.Option
+		err  func(error) bool
+	}{
+		{name: "any verified", ctx: withCert, err: status.IsOK},
+		{name: "allowed by cn", ctx: withCert, opts: []mtls.Option{mtls.Allow("orders")}, err: status.IsOK},
+		{name: "allowed by uri", ctx: withCert, opts: []mtls.Option{mtls.Allow("spiffe://example.org/orders")}, err: status.IsOK},
+		{name: "not allowed", ctx: withCert, opts: []mtls.Option{mtls.Allow("users")}, err: status.IsPermissionDenied},
+		{name: "missing cert", ctx: context.Background(), err: status.IsUnauthenticated},
+		{name: "optional cert", ctx: context.Background(), opts: []mtls.Option{mtls.Optional()}, err: status.IsOK},
+	}
+	for _, c := range cases {
+		ctx, err := mtls.Authenticator(c.opts...)(c.ctx)
+		if !c.err(err) {
+			t.Errorf("%s: got unexpected err=%v", c.name, err)
+			continue
+		}
+		if err == nil && c.ctx == withCert {
+			if id, ok := mtls.FromContext(ctx); !ok || id.CommonName != "orders" || id.URIs[0] != spiffe.String() {
+				t.Errorf("%s: got identity=%v, want identity of orders", c.name, id)
+			}
+		}
+	}
+}
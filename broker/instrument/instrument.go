@@ -0,0 +1,108 @@
+// Package instrument provides a broker.Broker decorator that adds OpenTelemetry
+// tracing to any broker implementation. The span context is propagated
+// via the message headers using W3C Trace Context by default.
+package instrument
+
+import (
+	"context"
+
+	"github.com/pthethanh/micro/broker"
+	"github.com/pthethanh/micro/health"
+	"github.com/pthethanh/micro/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Broker is an instrumented broker.Broker.
+	Broker struct {
+		broker.Broker
+		tracer     trace.Tracer
+		propagator propagation.TextMapPropagator
+	}
+
+	event struct {
+		broker.Event
+		ctx context.Context
+	}
+)
+
+var (
+	_ broker.Broker  = (*Broker)(nil)
+	_ health.Checker = (*Broker)(nil)
+)
+
+// New return new instrumented broker which wraps the given broker.
+func New(b broker.Broker, opts ...tracing.Option) *Broker {
+	o := tracing.NewOptions(opts...)
+	return &Broker{
+		Broker:     b,
+		tracer:     o.Tracer(),
+		propagator: o.Propagator,
+	}
+}
+
+// Publish implements broker.Broker. It starts a producer span and injects
+// its span context into the headers of the message.
+func (b *Broker) Publish(ctx context.Context, topic string, m *broker.Message, opts ...broker.PublishOption) error {
+	ctx, span := b.tracer.Start(ctx, topic+" publish", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("messaging.operation", "publish"),
+		attribute.String("messaging.destination.name", topic),
+	))
+	defer span.End()
+	if m.Header == nil {
+		m.Header = make(map[string]string)
+	}
+	b.propagator.Inject(ctx, propagation.MapCarrier(m.Header))
+	err := b.Broker.Publish(ctx, topic, m, opts...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// Subscribe implements broker.Broker. For each message, it starts a consumer span
+// as a child of the span context extracted from the headers of the message.
+// Use Context to get the context of the consumer span in the handler.
+func (b *Broker) Subscribe(ctx context.Context, topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	return b.Broker.Subscribe(ctx, topic, func(e broker.Event) error {
+		ctx := b.propagator.Extract(context.Background(), propagation.MapCarrier(e.Message().Header))
+		ctx, span := b.tracer.Start(ctx, e.Topic()+" receive", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+			attribute.String("messaging.operation", "receive"),
+			attribute.String("messaging.destination.name", e.Topic()),
+		))
+		defer span.End()
+		err := h(&event{Event: e, ctx: ctx})
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}, opts...)
+}
+
+// CheckHealth implements health.Checker if the underlying broker supports health check.
+func (b *Broker) CheckHealth(ctx context.Context) error {
+	if checker, ok := b.Broker.(health.Checker); ok {
+		return checker.CheckHealth(ctx)
+	}
+	return nil
+}
+
+// Unwrap return the underlying broker.
+func (b *Broker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+// Context return the context of the consumer span of the given event if the event is
+// delivered by an instrumented broker. Otherwise return a context with the span context
+// extracted from the headers of the message.
+func Context(e broker.Event) context.Context {
+	if ev, ok := e.(*event); ok {
+		return ev.ctx
+	}
+	return tracing.Extract(context.Background(), e.Message().Header)
+}
@@ -0,0 +1,78 @@
+package instrument_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/broker"
+	"github.com/pthethanh/micro/broker/instrument"
+	"github.com/pthethanh/micro/broker/memory"
+	"github.com/pthethanh/micro/tracing"
+	"github.com/pthethanh/micro/tracing/tracingtest"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInstrument(t *testing.T) {
+	rec := tracingtest.NewRecorder()
+	b := instrument.New(memory.New(), tracing.WithTracerProvider(rec))
+	ctx := context.Background()
+	if err := b.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close(ctx)
+	if err := b.CheckHealth(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan trace.SpanContext, 1)
+	sub, err := b.Subscribe(ctx, "test", func(e broker.Event) error {
+		ch <- trace.SpanContextFromContext(instrument.Context(e))
+		return errors.New("failed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	pctx, parent := rec.Tracer("test").Start(ctx, "parent")
+	if err := b.Publish(pctx, "test", broker.Must(broker.NewMessage(&struct{}{}, ""))); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+	var got trace.SpanContext
+	select {
+	case got = <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	if got.TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("got trace_id=%s, want trace_id=%s", got.TraceID(), parent.SpanContext().TraceID())
+	}
+	// the receive span ends after the handler returns.
+	var receive tracetest.SpanStub
+	var ok bool
+	for i := 0; i < 100 && !ok; i++ {
+		receive, ok = rec.Span("test receive")
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("got no receive span, want receive span")
+	}
+	if receive.SpanContext.SpanID() != got.SpanID() {
+		t.Errorf("got span_id=%s, want span_id=%s", got.SpanID(), receive.SpanContext.SpanID())
+	}
+	if receive.Status.Code != codes.Error {
+		t.Errorf("got status=%v, want status=%v", receive.Status.Code, codes.Error)
+	}
+	publish, ok := rec.Span("test publish")
+	if !ok {
+		t.Fatal("got no publish span, want publish span")
+	}
+	if receive.Parent.SpanID() != publish.SpanContext.SpanID() {
+		t.Errorf("got parent span_id=%s, want span_id=%s", receive.Parent.SpanID(), publish.SpanContext.SpanID())
+	}
+}
@@ -2,13 +2,22 @@ package cache
 
 import (
 	"context"
+	"math/rand"
 	"time"
 )
 
 type (
 	// SetOptions hold options when setting value for a key.
 	SetOptions struct {
+		// TTL is time to live of the key, zero means no expiration.
 		TTL time.Duration
+		// Jitter is the max random duration added to TTL
+		// to avoid many keys expire at the same time.
+		Jitter time.Duration
+		// Sliding extends expiration of the key by TTL on every successful Get.
+		Sliding bool
+		// KeepTTL retains the remaining TTL of the key if it already exists.
+		KeepTTL bool
 	}
 	// SetOption is option when setting value for a key.
 	SetOption func(*SetOptions)
@@ -26,6 +35,18 @@ type (
 		// Close close the underlying connection.
 		Close(ctx context.Context) error
 	}
+
+	// TTLGetter is an optional interface of a cache service that supports TTL introspection.
+	TTLGetter interface {
+		// TTL return remaining time to live of the key, return NoExpiration
+		// if the key has no expiration and ErrNotFound if key not found.
+		TTL(ctx context.Context, key string) (time.Duration, error)
+	}
+)
+
+const (
+	// NoExpiration is TTL of keys that have no expiration.
+	NoExpiration time.Duration = -1
 )
 
 // TTL is an option to set Time To Live for a key.
@@ -35,6 +56,38 @@ func TTL(ttl time.Duration) SetOption {
 	}
 }
 
+// Jitter is an option to add a random duration in range [0, d) to TTL
+// to avoid synchronized expiration of many keys.
+func Jitter(d time.Duration) SetOption {
+	return func(opts *SetOptions) {
+		opts.Jitter = d
+	}
+}
+
+// Sliding is an option to extend expiration of the key by TTL on every successful Get.
+// It has no effect if TTL is not set.
+func Sliding() SetOption {
+	return func(opts *SetOptions) {
+		opts.Sliding = true
+	}
+}
+
+// KeepTTL is an option to retain the remaining TTL of the key when overwriting its value.
+// If the key doesn't exist, TTL option is used instead.
+func KeepTTL() SetOption {
+	return func(opts *SetOptions) {
+		opts.KeepTTL = true
+	}
+}
+
+// Expiration return TTL with a random jitter added if Jitter is configured.
+func (opt *SetOptions) Expiration() time.Duration {
+	if opt.TTL <= 0 || opt.Jitter <= 0 {
+		return opt.TTL
+	}
+	return opt.TTL + time.Duration(rand.Int63n(int64(opt.Jitter)))
+}
+
 // Apply apply the options.
 func (opt *SetOptions) Apply(opts ...SetOption) {
 	for _, op := range opts {
@@ -0,0 +1,180 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v3.10.1
+// source: cache/cachepb/cache.proto
+
+package cachepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CacheOptions defines caching policy of a RPC method.
+type CacheOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// TTL of the cached responses in seconds.
+	TtlSeconds int64 `protobuf:"varint,1,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+}
+
+func (x *CacheOptions) Reset() {
+	*x = CacheOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_cache_cachepb_cache_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CacheOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheOptions) ProtoMessage() {}
+
+func (x *CacheOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_cache_cachepb_cache_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheOptions.ProtoReflect.Descriptor instead.
+func (*CacheOptions) Descriptor() ([]byte, []int) {
+	return file_cache_cachepb_cache_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CacheOptions) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+var file_cache_cachepb_cache_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*CacheOptions)(nil),
+		Field:         51001,
+		Name:          "micro.cache.cache",
+		Tag:           "bytes,51001,opt,name=cache",
+		Filename:      "cache/cachepb/cache.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// cache enables response caching of the method, the method must be idempotent.
+	// See server.CacheUnaryInterceptor for more detail.
+	//
+	// optional micro.cache.CacheOptions cache = 51001;
+	E_Cache = &file_cache_cachepb_cache_proto_extTypes[0]
+)
+
+var File_cache_cachepb_cache_proto protoreflect.FileDescriptor
+
+var file_cache_cachepb_cache_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2f, 0x63, 0x61, 0x63, 0x68, 0x65, 0x70, 0x62, 0x2f,
+	0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x6d, 0x69, 0x63,
+	0x72, 0x6f, 0x2e, 0x63, 0x61, 0x63, 0x68, 0x65, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x2f, 0x0a, 0x0c, 0x43, 0x61,
+	0x63, 0x68, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x74,
+	0x6c, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0a, 0x74, 0x74, 0x6c, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x3a, 0x51, 0x0a, 0x05, 0x63,
+	0x61, 0x63, 0x68, 0x65, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0xb9, 0x8e, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6d,
+	0x69, 0x63, 0x72, 0x6f, 0x2e, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x43, 0x61, 0x63, 0x68, 0x65,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x05, 0x63, 0x61, 0x63, 0x68, 0x65, 0x42, 0x32,
+	0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x74, 0x68,
+	0x65, 0x74, 0x68, 0x61, 0x6e, 0x68, 0x2f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x2f, 0x63, 0x61, 0x63,
+	0x68, 0x65, 0x2f, 0x63, 0x61, 0x63, 0x68, 0x65, 0x70, 0x62, 0x3b, 0x63, 0x61, 0x63, 0x68, 0x65,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_cache_cachepb_cache_proto_rawDescOnce sync.Once
+	file_cache_cachepb_cache_proto_rawDescData = file_cache_cachepb_cache_proto_rawDesc
+)
+
+func file_cache_cachepb_cache_proto_rawDescGZIP() []byte {
+	file_cache_cachepb_cache_proto_rawDescOnce.Do(func() {
+		file_cache_cachepb_cache_proto_rawDescData = protoimpl.X.CompressGZIP(file_cache_cachepb_cache_proto_rawDescData)
+	})
+	return file_cache_cachepb_cache_proto_rawDescData
+}
+
+var file_cache_cachepb_cache_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_cache_cachepb_cache_proto_goTypes = []interface{}{
+	(*CacheOptions)(nil),               // 0: micro.cache.CacheOptions
+	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
+}
+var file_cache_cachepb_cache_proto_depIdxs = []int32{
+	1, // 0: micro.cache.cache:extendee -> google.protobuf.MethodOptions
+	0, // 1: micro.cache.cache:type_name -> micro.cache.CacheOptions
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_cache_cachepb_cache_proto_init() }
+func file_cache_cachepb_cache_proto_init() {
+	if File_cache_cachepb_cache_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_cache_cachepb_cache_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CacheOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_cache_cachepb_cache_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_cache_cachepb_cache_proto_goTypes,
+		DependencyIndexes: file_cache_cachepb_cache_proto_depIdxs,
+		MessageInfos:      file_cache_cachepb_cache_proto_msgTypes,
+		ExtensionInfos:    file_cache_cachepb_cache_proto_extTypes,
+	}.Build()
+	File_cache_cachepb_cache_proto = out.File
+	file_cache_cachepb_cache_proto_rawDesc = nil
+	file_cache_cachepb_cache_proto_goTypes = nil
+	file_cache_cachepb_cache_proto_depIdxs = nil
+}
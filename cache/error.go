@@ -5,4 +5,6 @@ import "errors"
 var (
 	// ErrNotFound is an error report that the key is not found.
 	ErrNotFound = errors.New("cache: key not found")
+	// ErrNotSupported is an error report that the operation is not supported by the cache.
+	ErrNotSupported = errors.New("cache: operation not supported")
 )
@@ -0,0 +1,192 @@
+// Package instrument provides a cache.Cacher decorator that adds metrics,
+// tracing and logging to any cache implementation.
+package instrument
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pthethanh/micro/cache"
+	"github.com/pthethanh/micro/health"
+	"github.com/pthethanh/micro/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Cacher is an instrumented cache.Cacher.
+	Cacher struct {
+		cache.Cacher
+		name    string
+		tracer  opentracing.Tracer
+		otel    trace.Tracer
+		log     log.Logger
+		metrics *Metrics
+		// recordKey records the raw keys in spans and logs, see RecordKey.
+		recordKey bool
+	}
+
+	// Option is an option to configure the instrumented cacher.
+	Option func(*Cacher)
+)
+
+// Operations of cache.
+const (
+	OpGet    = "get"
+	OpSet    = "set"
+	OpDelete = "delete"
+	OpTTL    = "ttl"
+)
+
+var (
+	_ cache.Cacher    = (*Cacher)(nil)
+	_ cache.TTLGetter = (*Cacher)(nil)
+	_ health.Checker  = (*Cacher)(nil)
+)
+
+// New return new instrumented cacher which wraps the given cacher.
+// Metrics are recorded to DefaultMetrics by default, tracing and logging
+// are disabled unless OpenTelemetry, Tracer and Logger options are provided.
+func New(c cache.Cacher, opts ...Option) *Cacher {
+	ic := &Cacher{
+		Cacher:  c,
+		name:    "default",
+		metrics: DefaultMetrics,
+	}
+	for _, opt := range opts {
+		opt(ic)
+	}
+	return ic
+}
+
+// Get implements cache.Cacher.
+func (c *Cacher) Get(ctx context.Context, key string) ([]byte, error) {
+	var v []byte
+	err := c.observe(ctx, OpGet, key, func(ctx context.Context) (int, error) {
+		var err error
+		v, err = c.Cacher.Get(ctx, key)
+		return len(v), err
+	})
+	return v, err
+}
+
+// Set implements cache.Cacher.
+func (c *Cacher) Set(ctx context.Context, key string, val []byte, opts ...cache.SetOption) error {
+	return c.observe(ctx, OpSet, key, func(ctx context.Context) (int, error) {
+		return len(val), c.Cacher.Set(ctx, key, val, opts...)
+	})
+}
+
+// Delete implements cache.Cacher.
+func (c *Cacher) Delete(ctx context.Context, key string) error {
+	return c.observe(ctx, OpDelete, key, func(ctx context.Context) (int, error) {
+		return 0, c.Cacher.Delete(ctx, key)
+	})
+}
+
+// TTL implements cache.TTLGetter if the underlying cacher supports it,
+// otherwise return cache.ErrNotSupported.
+func (c *Cacher) TTL(ctx context.Context, key string) (time.Duration, error) {
+	getter, ok := c.Cacher.(cache.TTLGetter)
+	if !ok {
+		return 0, cache.ErrNotSupported
+	}
+	var ttl time.Duration
+	err := c.observe(ctx, OpTTL, key, func(ctx context.Context) (int, error) {
+		var err error
+		ttl, err = getter.TTL(ctx, key)
+		return 0, err
+	})
+	return ttl, err
+}
+
+// CheckHealth implements health.Checker if the underlying cacher supports health check.
+func (c *Cacher) CheckHealth(ctx context.Context) error {
+	if checker, ok := c.Cacher.(health.Checker); ok {
+		return checker.CheckHealth(ctx)
+	}
+	return nil
+}
+
+// Unwrap return the underlying cacher.
+func (c *Cacher) Unwrap() cache.Cacher {
+	return c.Cacher
+}
+
+func (c *Cacher) observe(ctx context.Context, op string, key string, f func(ctx context.Context) (int, error)) error {
+	var span opentracing.Span
+	if c.tracer != nil {
+		span, ctx = opentracing.StartSpanFromContextWithTracer(ctx, c.tracer, "cache."+op)
+		ext.Component.Set(span, "cache")
+		span.SetTag("cache.name", c.name)
+		if c.recordKey {
+			span.SetTag("cache.key", key)
+		}
+		defer span.Finish()
+	}
+	var otelSpan trace.Span
+	if c.otel != nil {
+		attrs := []attribute.KeyValue{
+			attribute.String("cache.name", c.name),
+			attribute.String("cache.operation", op),
+		}
+		if c.recordKey {
+			attrs = append(attrs, attribute.String("cache.key", key))
+		}
+		ctx, otelSpan = c.otel.Start(ctx, "cache."+op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		defer otelSpan.End()
+	}
+	bg := time.Now()
+	size, err := f(ctx)
+	duration := time.Since(bg)
+	result := getResult(op, err)
+	if c.metrics != nil {
+		c.metrics.requests.WithLabelValues(c.name, op, result).Inc()
+		c.metrics.latency.WithLabelValues(c.name, op).Observe(duration.Seconds())
+		if (op == OpGet || op == OpSet) && err == nil {
+			c.metrics.size.WithLabelValues(c.name, op).Observe(float64(size))
+		}
+	}
+	if span != nil {
+		span.SetTag("cache.result", result)
+		if result == ResultError {
+			ext.Error.Set(span, true)
+			span.LogKV("error", err)
+		}
+	}
+	if otelSpan != nil {
+		otelSpan.SetAttributes(attribute.String("cache.result", result))
+		if result == ResultError {
+			otelSpan.RecordError(err)
+			otelSpan.SetStatus(codes.Error, err.Error())
+		}
+	}
+	if c.log != nil {
+		kv := []interface{}{"cache", c.name, "operation", op, "result", result, "duration", duration}
+		if c.recordKey {
+			kv = append(kv, "key", key)
+		}
+		if err != nil {
+			kv = append(kv, "error", err)
+		}
+		c.log.Context(ctx).Fields(kv...).Debug("cache: operation completed")
+	}
+	return err
+}
+
+func getResult(op string, err error) string {
+	switch {
+	case (op == OpGet || op == OpTTL) && errors.Is(err, cache.ErrNotFound):
+		return ResultMiss
+	case err != nil:
+		return ResultError
+	case op == OpGet || op == OpTTL:
+		return ResultHit
+	default:
+		return ResultOK
+	}
+}
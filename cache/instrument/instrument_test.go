@@ -0,0 +1,168 @@
+package instrument_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/pthethanh/micro/cache"
+	"github.com/pthethanh/micro/cache/instrument"
+	"github.com/pthethanh/micro/cache/memory"
+	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/tracing"
+	"github.com/pthethanh/micro/tracing/tracingtest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestInstrument(t *testing.T) {
+	metrics := instrument.NewMetrics()
+	reg := prometheus.NewRegistry()
+	if err := metrics.Register(reg); err != nil {
+		t.Fatal(err)
+	}
+	// register twice should be ok.
+	if err := metrics.Register(reg); err != nil {
+		t.Fatal(err)
+	}
+	tracer := mocktracer.New()
+	var c cache.Cacher = instrument.New(memory.New(), instrument.Name("test"), instrument.Tracer(tracer), instrument.WithMetrics(metrics))
+	ctx := context.Background()
+	if err := c.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(ctx)
+
+	if err := c.Set(ctx, "k", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "not_found"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		op     string
+		result string
+		want   float64
+	}{
+		{op: instrument.OpSet, result: instrument.ResultOK, want: 1},
+		{op: instrument.OpGet, result: instrument.ResultHit, want: 2},
+		{op: instrument.OpGet, result: instrument.ResultMiss, want: 1},
+		{op: instrument.OpDelete, result: instrument.ResultOK, want: 1},
+	}
+	for _, c := range cases {
+		if got := counterValue(families, "cache_requests_total", "test", c.op, c.result); got != c.want {
+			t.Errorf("got %s/%s=%v, want %v", c.op, c.result, got, c.want)
+		}
+	}
+	if n := len(tracer.FinishedSpans()); n != 5 {
+		t.Errorf("got spans=%d, want spans=%d", n, 5)
+	}
+	for _, span := range tracer.FinishedSpans() {
+		if key := span.Tag("cache.key"); key != nil {
+			t.Errorf("got cache.key=%v, want no key recorded by default", key)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, err := log.NewLogrus(log.WithWriter(buf), log.WithLevel(log.LevelDebug))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := instrument.New(memory.New(), instrument.Logger(logger), instrument.WithMetrics(nil))
+	ctx := context.Background()
+	if err := c.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(ctx)
+
+	if err := c.Set(ctx, "secret", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "not_found"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got logs=%s, want 2 lines", buf.String())
+	}
+	if strings.Contains(lines[0], `"error"`) || strings.Contains(lines[0], "secret") {
+		t.Errorf("got log=%s, want no error and key", lines[0])
+	}
+	if !strings.Contains(lines[1], `"error"`) {
+		t.Errorf("got log=%s, want error", lines[1])
+	}
+}
+
+func TestOpenTelemetry(t *testing.T) {
+	rec := tracingtest.NewRecorder()
+	c := instrument.New(memory.New(), instrument.Name("test"), instrument.OpenTelemetry(tracing.WithTracerProvider(rec)), instrument.WithMetrics(nil), instrument.RecordKey())
+	ctx := context.Background()
+	if err := c.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(ctx)
+
+	if _, err := c.Get(ctx, "not_found"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+	span, ok := rec.Span("cache." + instrument.OpGet)
+	if !ok {
+		t.Fatal("got no span, want span cache.get")
+	}
+	want := map[attribute.Key]string{
+		"cache.name":   "test",
+		"cache.key":    "not_found",
+		"cache.result": instrument.ResultMiss,
+	}
+	got := make(map[attribute.Key]string)
+	for _, attr := range span.Attributes {
+		got[attr.Key] = attr.Value.AsString()
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %s=%s, want %s=%s", k, got[k], k, v)
+		}
+	}
+	if span.Status.Code == codes.Error {
+		t.Errorf("got status=%v, want cache miss is not an error", span.Status.Code)
+	}
+}
+
+func counterValue(families []*dto.MetricFamily, name string, labels ...string) float64 {
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range f.GetMetric() {
+			for i, l := range m.GetLabel() {
+				if l.GetValue() != labels[i] {
+					continue next
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package instrument
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// Metrics holds Prometheus collectors of cache operations.
+	Metrics struct {
+		requests *prometheus.CounterVec
+		latency  *prometheus.HistogramVec
+		size     *prometheus.HistogramVec
+	}
+)
+
+// Results of cache operations.
+const (
+	ResultHit   = "hit"
+	ResultMiss  = "miss"
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+var (
+	_ prometheus.Collector = (*Metrics)(nil)
+
+	// DefaultMetrics is the default metrics used by all instrumented cachers.
+	// It is registered automatically to the default Prometheus registry by the server
+	// when metrics are enabled.
+	DefaultMetrics = NewMetrics()
+)
+
+// NewMetrics return new cache metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Total number of cache operations by result: hit, miss, ok or error.",
+		}, []string{"cache", "operation", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_request_duration_seconds",
+			Help:    "Latency of cache operations.",
+			Buckets: []float64{.0001, .0005, .001, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"cache", "operation"}),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_value_size_bytes",
+			Help:    "Size of values read from or written to the cache.",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+		}, []string{"cache", "operation"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.latency.Describe(ch)
+	m.size.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.latency.Collect(ch)
+	m.size.Collect(ch)
+}
+
+// Register registers the metrics to the given registerer.
+// It's safe to call Register multiple times.
+func (m *Metrics) Register(r prometheus.Registerer) error {
+	if err := r.Register(m); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package instrument
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/tracing"
+)
+
+// Name is an option to set name of the cache, used as label of metrics, tags of spans and logs.
+// Default name is "default".
+func Name(name string) Option {
+	return func(c *Cacher) {
+		c.name = name
+	}
+}
+
+// OpenTelemetry is an option to enable OpenTelemetry spans for all cache operations.
+func OpenTelemetry(opts ...tracing.Option) Option {
+	return func(c *Cacher) {
+		c.otel = tracing.NewOptions(opts...).Tracer()
+	}
+}
+
+// Tracer is an option to enable opentracing spans for all cache operations.
+//
+// Deprecated: OpenTracing is deprecated, use OpenTelemetry instead.
+func Tracer(tracer opentracing.Tracer) Option {
+	return func(c *Cacher) {
+		c.tracer = tracer
+	}
+}
+
+// Logger is an option to enable debug logs for all cache operations.
+// The logger is combined with the context logger via log.Context.
+func Logger(l log.Logger) Option {
+	return func(c *Cacher) {
+		c.log = l
+	}
+}
+
+// RecordKey is an option to record the raw cache keys as tags of spans and fields of logs.
+// Keys are not recorded by default as they might contain sensitive data, i.e: user ids, emails.
+func RecordKey() Option {
+	return func(c *Cacher) {
+		c.recordKey = true
+	}
+}
+
+// WithMetrics is an option to override the default metrics.
+// Use nil to disable metrics.
+func WithMetrics(m *Metrics) Option {
+	return func(c *Cacher) {
+		c.metrics = m
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pthethanh/micro/cache"
 	"github.com/pthethanh/micro/health"
+	"github.com/pthethanh/micro/log"
 )
 
 type (
@@ -18,18 +19,26 @@ type (
 		exit     chan struct{}
 		opened   bool
 		shard    uint64
+
+		snapshotFile     string
+		snapshotInterval time.Duration
+		// snapshotWg waits for the periodic snapshot to stop on closing.
+		snapshotWg sync.WaitGroup
 	}
 	value struct {
-		val []byte
-		exp *time.Time
+		val     []byte
+		exp     *time.Time
+		ttl     time.Duration
+		sliding bool
 	}
 
 	Option func(*Memory)
 )
 
 var (
-	_ cache.Cacher   = (*Memory)(nil)
-	_ health.Checker = (*Memory)(nil)
+	_ cache.Cacher    = (*Memory)(nil)
+	_ cache.TTLGetter = (*Memory)(nil)
+	_ health.Checker  = (*Memory)(nil)
 
 	// ErrInvalidConnectionState indicate that the connection has not been opened properly.
 	ErrInvalidConnectionState = errors.New("invalid connection state")
@@ -65,18 +74,42 @@ func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
 	if !m.opened {
 		return nil, ErrInvalidConnectionState
 	}
-	if v, ok := m.getShard(key).Load(key); ok {
-		val := v.(value)
+	shard := m.getShard(key)
+	if v, ok := shard.Load(key); ok {
+		val := v.(*value)
 		// if cleaner has not done its job yet, go ahead to delete
 		if val.expired() {
-			_ = m.Delete(ctx, key)
+			shard.CompareAndDelete(key, val)
 			return nil, cache.ErrNotFound
 		}
+		if val.sliding {
+			// only touch if the value has not been changed by others.
+			shard.CompareAndSwap(key, val, val.touch())
+		}
 		return val.val, nil
 	}
 	return nil, cache.ErrNotFound
 }
 
+// TTL return remaining time to live of the key.
+func (m *Memory) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if !m.opened {
+		return 0, ErrInvalidConnectionState
+	}
+	v, ok := m.getShard(key).Load(key)
+	if !ok {
+		return 0, cache.ErrNotFound
+	}
+	val := v.(*value)
+	if val.expired() {
+		return 0, cache.ErrNotFound
+	}
+	if val.exp == nil || val.exp.IsZero() {
+		return cache.NoExpiration, nil
+	}
+	return time.Until(*val.exp), nil
+}
+
 // Set a value.
 func (m *Memory) Set(ctx context.Context, key string, val []byte, opts ...cache.SetOption) error {
 	if !m.opened {
@@ -84,14 +117,23 @@ func (m *Memory) Set(ctx context.Context, key string, val []byte, opts ...cache.
 	}
 	opt := &cache.SetOptions{}
 	opt.Apply(opts...)
-	v := value{
+	v := &value{
 		val: val,
 	}
-	if opt.TTL != 0 {
-		t := time.Now().Add(opt.TTL)
+	if ttl := opt.Expiration(); ttl > 0 {
+		t := time.Now().Add(ttl)
 		v.exp = &t
+		v.ttl = opt.TTL
+		v.sliding = opt.Sliding
+	}
+	shard := m.getShard(key)
+	if opt.KeepTTL {
+		if old, ok := shard.Load(key); ok && !old.(*value).expired() {
+			old := old.(*value)
+			v.exp, v.ttl, v.sliding = old.exp, old.ttl, old.sliding
+		}
 	}
-	m.getShard(key).Store(key, v)
+	shard.Store(key, v)
 	return nil
 }
 
@@ -114,9 +156,9 @@ func (m *Memory) clean() {
 				select {
 				case <-tik.C:
 					m.values[i].Range(func(k, v interface{}) bool {
-						val := v.(value)
+						val := v.(*value)
 						if val.expired() {
-							m.values[i].Delete(k)
+							m.values[i].CompareAndDelete(k, val)
 						}
 						return true
 					})
@@ -128,7 +170,7 @@ func (m *Memory) clean() {
 	}
 }
 
-func (v value) expired() bool {
+func (v *value) expired() bool {
 	if v.exp == nil {
 		return false
 	}
@@ -138,17 +180,45 @@ func (v value) expired() bool {
 	return time.Now().After(*v.exp)
 }
 
+// touch return a copy of the value with expiration extended by its TTL.
+func (v *value) touch() *value {
+	t := time.Now().Add(v.ttl)
+	return &value{
+		val:     v.val,
+		exp:     &t,
+		ttl:     v.ttl,
+		sliding: v.sliding,
+	}
+}
+
 // Open make the cacher ready for using.
+// If snapshot is enabled, keys are restored from the snapshot file.
+// A corrupted snapshot is ignored and the cache starts empty.
 func (m *Memory) Open(ctx context.Context) error {
+	if m.snapshotFile != "" {
+		if err := m.loadSnapshot(); err != nil {
+			log.Context(ctx).Warnf("memory: load snapshot failed, file: %s, err: %v", m.snapshotFile, err)
+		}
+		if m.snapshotInterval > 0 {
+			m.snapshotWg.Add(1)
+			go m.snapshot()
+		}
+	}
 	go m.clean()
 	m.opened = true
 	return nil
 }
 
 // Close close underlying resources.
+// If snapshot is enabled, a last snapshot is saved before closing
+// once the periodic snapshot is stopped.
 func (m *Memory) Close(ctx context.Context) error {
 	m.opened = false
 	close(m.exit)
+	m.snapshotWg.Wait()
+	if m.snapshotFile != "" {
+		return m.saveSnapshot()
+	}
 	return nil
 }
 
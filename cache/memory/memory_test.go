@@ -1,7 +1,12 @@
 package memory_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -73,3 +78,189 @@ func TestCacheDelete(t *testing.T) {
 		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
 	}
 }
+
+func TestCacheTTL(t *testing.T) {
+	m := memory.New()
+	ctx := context.Background()
+	m.Open(ctx)
+	defer m.Close(ctx)
+
+	if _, err := m.TTL(ctx, "k"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+	if err := m.Set(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := m.TTL(ctx, "k"); err != nil || ttl != cache.NoExpiration {
+		t.Fatalf("got ttl=%v, err=%v, want ttl=%v, err=nil", ttl, err, cache.NoExpiration)
+	}
+	if err := m.Set(ctx, "k", []byte("v"), cache.TTL(time.Second), cache.Jitter(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := m.TTL(ctx, "k"); err != nil || ttl <= 0 || ttl > 2*time.Second {
+		t.Fatalf("got ttl=%v, err=%v, want ttl in range (0, 2s]", ttl, err)
+	}
+}
+
+func TestCacheKeepTTL(t *testing.T) {
+	m := memory.New()
+	ctx := context.Background()
+	m.Open(ctx)
+	defer m.Close(ctx)
+
+	if err := m.Set(ctx, "k", []byte("v1"), cache.TTL(300*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := m.Set(ctx, "k", []byte("v2"), cache.KeepTTL()); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := m.Get(ctx, "k"); err != nil || string(v) != "v2" {
+		t.Fatalf("got result=%v, err=%v, want result=%v, err=nil", string(v), err, "v2")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if _, err := m.Get(ctx, "k"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+	// key doesn't exist, TTL is used.
+	if err := m.Set(ctx, "k", []byte("v"), cache.KeepTTL(), cache.TTL(100*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if _, err := m.Get(ctx, "k"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+}
+
+func TestCacheSliding(t *testing.T) {
+	m := memory.New()
+	ctx := context.Background()
+	m.Open(ctx)
+	defer m.Close(ctx)
+
+	if err := m.Set(ctx, "k", []byte("v"), cache.TTL(300*time.Millisecond), cache.Sliding()); err != nil {
+		t.Fatal(err)
+	}
+	// keep touching the key, it should never expire.
+	for i := 0; i < 4; i++ {
+		time.Sleep(150 * time.Millisecond)
+		if _, err := m.Get(ctx, "k"); err != nil {
+			t.Fatalf("got err=%v, want err=nil", err)
+		}
+	}
+	time.Sleep(350 * time.Millisecond)
+	if _, err := m.Get(ctx, "k"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+}
+
+func TestCacheSnapshot(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "cache.snapshot")
+	m := memory.New(memory.Snapshot(file, 100*time.Millisecond))
+	if err := m.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(ctx, "k1", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(ctx, "k2", []byte("v2"), cache.TTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(ctx, "k3", []byte("v3"), cache.TTL(50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	// wait for periodic snapshot.
+	time.Sleep(150 * time.Millisecond)
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("got err=%v, want snapshot file created", err)
+	}
+	if err := m.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	m = memory.New(memory.Snapshot(file, 0))
+	if err := m.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+	if v, err := m.Get(ctx, "k1"); err != nil || string(v) != "v1" {
+		t.Fatalf("got result=%v, err=%v, want result=%v, err=nil", string(v), err, "v1")
+	}
+	if ttl, err := m.TTL(ctx, "k2"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("got ttl=%v, err=%v, want ttl in range (0, 1m]", ttl, err)
+	}
+	if _, err := m.Get(ctx, "k3"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+}
+
+func TestCacheSnapshotClose(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := memory.New(memory.Snapshot(filepath.Join(dir, "cache.snapshot"), time.Millisecond))
+	if err := m.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		m.Set(ctx, strconv.Itoa(i), bytes.Repeat([]byte("v"), 1024))
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := m.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the periodic snapshot must be stopped, no temporary file is left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "cache.snapshot" {
+		t.Fatalf("got files=%v, want only the snapshot file", entries)
+	}
+}
+
+func TestCacheSnapshotCorrupted(t *testing.T) {
+	ctx := context.Background()
+	m := memory.New()
+	m.Open(ctx)
+	defer m.Close(ctx)
+	if err := m.Set(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	cases := map[string][]byte{
+		"empty":     {},
+		"truncated": b[:len(b)-5],
+		"corrupted": append(append([]byte{}, b[:10]...), append([]byte{b[10] ^ 0xff}, b[11:]...)...),
+		"magic":     append([]byte("XXXX"), b[4:]...),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := memory.New()
+			m.Open(ctx)
+			defer m.Close(ctx)
+			if _, err := m.ReadFrom(bytes.NewReader(data)); !errors.Is(err, memory.ErrInvalidSnapshot) {
+				t.Fatalf("got err=%v, want err=%v", err, memory.ErrInvalidSnapshot)
+			}
+			if _, err := m.Get(ctx, "k"); err != cache.ErrNotFound {
+				t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+			}
+		})
+	}
+
+	// valid snapshot.
+	n := memory.New()
+	n.Open(ctx)
+	defer n.Close(ctx)
+	if _, err := n.ReadFrom(bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := n.Get(ctx, "k"); err != nil || string(v) != "v" {
+		t.Fatalf("got result=%v, err=%v, want result=%v, err=nil", string(v), err, "v")
+	}
+}
@@ -15,3 +15,13 @@ func Shard(shard uint64) Option {
 		m.shard = shard
 	}
 }
+
+// Snapshot is an option to persist the cache to the given file every interval
+// and on Close, so that the cache can be restored from the file on Open.
+// Use interval <= 0 to persist on Close only.
+func Snapshot(file string, interval time.Duration) Option {
+	return func(m *Memory) {
+		m.snapshotFile = file
+		m.snapshotInterval = interval
+	}
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pthethanh/micro/log"
+)
+
+// Snapshot format (all integers are big endian):
+//
+//	magic    [4]byte "MMCS"
+//	version  uint32
+//	entries  repeated:
+//	           key_len uvarint, key, val_len uvarint, val,
+//	           exp int64 (unix nano, 0 means no expiration),
+//	           ttl int64, sliding byte
+//	checksum uint32 CRC-32 (IEEE) of all previous bytes.
+const (
+	snapshotMagic   = "MMCS"
+	snapshotVersion = uint32(1)
+)
+
+var (
+	// ErrInvalidSnapshot indicate that the snapshot is corrupted or not supported.
+	ErrInvalidSnapshot = errors.New("memory: invalid snapshot")
+)
+
+// WriteTo writes a snapshot of all unexpired keys to w.
+// It implements io.WriterTo.
+func (m *Memory) WriteTo(w io.Writer) (int64, error) {
+	h := crc32.NewIEEE()
+	cw := &countWriter{w: io.MultiWriter(w, h)}
+	bw := bufio.NewWriter(cw)
+	bw.WriteString(snapshotMagic)
+	binary.Write(bw, binary.BigEndian, snapshotVersion)
+	buf := make([]byte, binary.MaxVarintLen64)
+	for i := 0; i < int(m.shard); i++ {
+		m.values[i].Range(func(k, v interface{}) bool {
+			key, val := k.(string), v.(*value)
+			if val.expired() {
+				return true
+			}
+			var exp int64
+			if val.exp != nil && !val.exp.IsZero() {
+				exp = val.exp.UnixNano()
+			}
+			bw.Write(buf[:binary.PutUvarint(buf, uint64(len(key)))])
+			bw.WriteString(key)
+			bw.Write(buf[:binary.PutUvarint(buf, uint64(len(val.val)))])
+			bw.Write(val.val)
+			binary.Write(bw, binary.BigEndian, exp)
+			binary.Write(bw, binary.BigEndian, int64(val.ttl))
+			sliding := byte(0)
+			if val.sliding {
+				sliding = 1
+			}
+			bw.WriteByte(sliding)
+			return true
+		})
+	}
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	// checksum is written directly to w, it's not a part of the checksum.
+	if err := binary.Write(w, binary.BigEndian, h.Sum32()); err != nil {
+		return cw.n, err
+	}
+	return cw.n + 4, nil
+}
+
+// ReadFrom loads keys from a snapshot written by WriteTo.
+// Expired keys are ignored and existing keys are overridden.
+// ErrInvalidSnapshot is returned if the snapshot is corrupted, in that case nothing is loaded.
+// It implements io.ReaderFrom.
+func (m *Memory) ReadFrom(r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	n := int64(len(data))
+	if err != nil {
+		return n, err
+	}
+	if len(data) < len(snapshotMagic)+8 || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return n, ErrInvalidSnapshot
+	}
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return n, fmt.Errorf("%w: checksum mismatch", ErrInvalidSnapshot)
+	}
+	if v := binary.BigEndian.Uint32(body[len(snapshotMagic):]); v != snapshotVersion {
+		return n, fmt.Errorf("%w: version %d not supported", ErrInvalidSnapshot, v)
+	}
+	br := bytes.NewReader(body[len(snapshotMagic)+4:])
+	values := make(map[string]*value)
+	for br.Len() > 0 {
+		key, err := readBytes(br)
+		if err != nil {
+			return n, err
+		}
+		val, err := readBytes(br)
+		if err != nil {
+			return n, err
+		}
+		var exp, ttl int64
+		if err := binary.Read(br, binary.BigEndian, &exp); err != nil {
+			return n, ErrInvalidSnapshot
+		}
+		if err := binary.Read(br, binary.BigEndian, &ttl); err != nil {
+			return n, ErrInvalidSnapshot
+		}
+		sliding, err := br.ReadByte()
+		if err != nil {
+			return n, ErrInvalidSnapshot
+		}
+		v := &value{
+			val:     val,
+			ttl:     time.Duration(ttl),
+			sliding: sliding == 1,
+		}
+		if exp != 0 {
+			t := time.Unix(0, exp)
+			v.exp = &t
+		}
+		values[string(key)] = v
+	}
+	for k, v := range values {
+		if v.expired() {
+			continue
+		}
+		m.getShard(k).Store(k, v)
+	}
+	return n, nil
+}
+
+func (m *Memory) loadSnapshot() error {
+	f, err := os.Open(m.snapshotFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = m.ReadFrom(f)
+	return err
+}
+
+// saveSnapshot writes the snapshot to a temporary file and then
+// rename it to the snapshot file so that the snapshot file is never partially written.
+func (m *Memory) saveSnapshot() error {
+	f, err := os.CreateTemp(filepath.Dir(m.snapshotFile), filepath.Base(m.snapshotFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := m.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), m.snapshotFile)
+}
+
+func (m *Memory) snapshot() {
+	defer m.snapshotWg.Done()
+	tik := time.NewTicker(m.snapshotInterval)
+	defer tik.Stop()
+	for {
+		select {
+		case <-tik.C:
+			if err := m.saveSnapshot(); err != nil {
+				log.Errorf("memory: save snapshot failed, file: %s, err: %v", m.snapshotFile, err)
+			}
+		case <-m.exit:
+			return
+		}
+	}
+}
+
+func readBytes(br *bytes.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(br)
+	if err != nil || l > uint64(br.Len()) {
+		return nil, ErrInvalidSnapshot
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(br, b); err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	return b, nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
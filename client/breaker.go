@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// CircuitBreakerOption is an option to configure circuit breaker interceptors.
+	CircuitBreakerOption func(*circuitBreaker)
+
+	circuitBreaker struct {
+		threshold int
+		timeout   time.Duration
+		halfOpen  int
+		codes     map[codes.Code]bool
+		metrics   *Metrics
+		breakers  sync.Map
+	}
+
+	breakerState int
+
+	// breaker is circuit breaker of a single target.
+	breaker struct {
+		mu       sync.Mutex
+		state    breakerState
+		failures int
+		openedAt time.Time
+		inflight int
+	}
+
+	breakerStream struct {
+		grpc.ClientStream
+		serverStream bool
+		done         func(error)
+	}
+)
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+var (
+	defaultBreakerCodes = []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown}
+)
+
+// BreakerThreshold is an option to set number of consecutive failures that opens the circuit. Default is 5.
+func BreakerThreshold(n int) CircuitBreakerOption {
+	return func(cb *circuitBreaker) {
+		cb.threshold = n
+	}
+}
+
+// BreakerOpenTimeout is an option to set duration the circuit stays open
+// before allowing trial requests. Default is 10s.
+func BreakerOpenTimeout(d time.Duration) CircuitBreakerOption {
+	return func(cb *circuitBreaker) {
+		cb.timeout = d
+	}
+}
+
+// BreakerHalfOpenRequests is an option to set maximum number of concurrent trial requests
+// while the circuit is half-open. Default is 1.
+func BreakerHalfOpenRequests(n int) CircuitBreakerOption {
+	return func(cb *circuitBreaker) {
+		cb.halfOpen = n
+	}
+}
+
+// BreakerCodes is an option to set codes that are counted as failures.
+// Default is codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal and codes.Unknown.
+func BreakerCodes(cs ...codes.Code) CircuitBreakerOption {
+	return func(cb *circuitBreaker) {
+		cb.codes = codeSet(cs)
+	}
+}
+
+// BreakerMetrics is an option to set the metrics of the circuit breakers. Default is DefaultMetrics.
+func BreakerMetrics(m *Metrics) CircuitBreakerOption {
+	return func(cb *circuitBreaker) {
+		cb.metrics = m
+	}
+}
+
+func newCircuitBreaker(opts ...CircuitBreakerOption) *circuitBreaker {
+	cb := &circuitBreaker{
+		threshold: 5,
+		timeout:   10 * time.Second,
+		halfOpen:  1,
+		codes:     codeSet(defaultBreakerCodes),
+		metrics:   DefaultMetrics,
+	}
+	for _, opt := range opts {
+		opt(cb)
+	}
+	return cb
+}
+
+// CircuitBreakerUnaryInterceptor return a grpc.UnaryClientInterceptor that maintains a circuit breaker per target.
+// The circuit is opened after a number of consecutive failures, requests are then rejected
+// immediately with codes.Unavailable until the open timeout passes and trial requests succeed.
+func CircuitBreakerUnaryInterceptor(opts ...CircuitBreakerOption) grpc.UnaryClientInterceptor {
+	cb := newCircuitBreaker(opts...)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b := cb.breaker(cc.Target())
+		if !cb.allow(b, cc.Target(), method) {
+			return status.Errorf(codes.Unavailable, "circuit breaker is open for target %s", cc.Target())
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		cb.done(b, cc.Target(), err)
+		return err
+	}
+}
+
+// CircuitBreakerStreamInterceptor return a grpc.StreamClientInterceptor that maintains a circuit breaker per target.
+// See CircuitBreakerUnaryInterceptor.
+func CircuitBreakerStreamInterceptor(opts ...CircuitBreakerOption) grpc.StreamClientInterceptor {
+	cb := newCircuitBreaker(opts...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		b := cb.breaker(cc.Target())
+		if !cb.allow(b, cc.Target(), method) {
+			return nil, status.Errorf(codes.Unavailable, "circuit breaker is open for target %s", cc.Target())
+		}
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cb.done(b, cc.Target(), err)
+			return nil, err
+		}
+		var once sync.Once
+		done := func(err error) {
+			once.Do(func() { cb.done(b, cc.Target(), err) })
+		}
+		// make sure the stream is counted done if the caller abandons it.
+		stop := context.AfterFunc(ctx, func() {
+			done(status.FromContextError(ctx.Err()).Err())
+		})
+		return &breakerStream{
+			ClientStream: s,
+			serverStream: desc.ServerStreams,
+			done: func(err error) {
+				// the stream is finished, the context callback is not needed anymore.
+				stop()
+				done(err)
+			},
+		}, nil
+	}
+}
+
+// WithCircuitBreaker return a dial option that uses circuit breaker for unary requests.
+// See CircuitBreakerUnaryInterceptor.
+func WithCircuitBreaker(opts ...CircuitBreakerOption) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(CircuitBreakerUnaryInterceptor(opts...))
+}
+
+// WithStreamCircuitBreaker return a dial option that uses circuit breaker for stream requests.
+// See CircuitBreakerStreamInterceptor.
+func WithStreamCircuitBreaker(opts ...CircuitBreakerOption) grpc.DialOption {
+	return grpc.WithChainStreamInterceptor(CircuitBreakerStreamInterceptor(opts...))
+}
+
+func (cb *circuitBreaker) breaker(target string) *breaker {
+	if b, ok := cb.breakers.Load(target); ok {
+		return b.(*breaker)
+	}
+	b, _ := cb.breakers.LoadOrStore(target, &breaker{})
+	return b.(*breaker)
+}
+
+func (cb *circuitBreaker) allow(b *breaker, target, method string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen && time.Since(b.openedAt) >= cb.timeout {
+		cb.setState(b, target, breakerHalfOpen)
+	}
+	switch {
+	case b.state == breakerOpen, b.state == breakerHalfOpen && b.inflight >= cb.halfOpen:
+		cb.metrics.breakerReject.WithLabelValues(target, method).Inc()
+		return false
+	}
+	b.inflight++
+	return true
+}
+
+func (cb *circuitBreaker) done(b *breaker, target string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inflight--
+	if err != nil && cb.codes[status.Code(err)] {
+		b.failures++
+		if b.state == breakerHalfOpen || b.failures >= cb.threshold {
+			b.openedAt = time.Now()
+			cb.setState(b, target, breakerOpen)
+		}
+		return
+	}
+	b.failures = 0
+	if b.state == breakerHalfOpen {
+		cb.setState(b, target, breakerClosed)
+	}
+}
+
+func (cb *circuitBreaker) setState(b *breaker, target string, state breakerState) {
+	b.state = state
+	cb.metrics.breakerState.WithLabelValues(target).Set(float64(state))
+}
+
+// RecvMsg implements grpc.ClientStream.
+func (s *breakerStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == io.EOF || (err == nil && !s.serverStream) {
+		s.done(nil)
+	} else if err != nil {
+		s.done(err)
+	}
+	return err
+}
@@ -3,7 +3,10 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 
 	"github.com/google/uuid"
 	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
@@ -12,6 +15,8 @@ import (
 	"github.com/pthethanh/micro/config"
 	"github.com/pthethanh/micro/config/envconfig"
 	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/registry"
+	"github.com/pthethanh/micro/tracing"
 	"github.com/pthethanh/micro/util/contextutil"
 	"google.golang.org/grpc"
 
@@ -29,6 +34,10 @@ type (
 		Address     string `envconfig:"ADDRESS" default:"localhost:8000"`
 		TLSCertFile string `envconfig:"TLS_CERT_FILE"`
 		JWTToken    string `envconfig:"JWT_TOKEN"`
+		// TLSClientCertFile and TLSClientKeyFile are paths to the client certificate
+		// and key presented to the server for mutual TLS.
+		TLSClientCertFile string `envconfig:"TLS_CLIENT_CERT_FILE"`
+		TLSClientKeyFile  string `envconfig:"TLS_CLIENT_KEY_FILE"`
 	}
 
 	addressOption struct {
@@ -56,7 +65,9 @@ func WithConfig(conf *Config) []grpc.DialOption {
 	if conf.JWTToken != "" {
 		opts = append(opts, WithJWTCredentials(conf.JWTToken))
 	}
-	if conf.TLSCertFile != "" {
+	if conf.TLSCertFile != "" && conf.TLSClientCertFile != "" {
+		opts = append(opts, WithMutualTLS(conf.TLSCertFile, conf.TLSClientCertFile, conf.TLSClientKeyFile))
+	} else if conf.TLSCertFile != "" {
 		opts = append(opts, WithTLSTransportCredentials(conf.TLSCertFile))
 	} else {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -85,8 +96,18 @@ func DialContext(ctx context.Context, address string, options ...grpc.DialOption
 	if address == "" {
 		address = GetAddressFromEnv()
 	}
-	opts := append([]grpc.DialOption{}, options...)
-	if len(opts) == 0 {
+	opts := make([]grpc.DialOption, 0, len(options))
+	custom := 0
+	for _, opt := range options {
+		if _, ok := opt.(defaultsOption); ok {
+			opts = append(opts, defaultDialOptions()...)
+			continue
+		}
+		opts = append(opts, opt)
+		custom++
+	}
+	// insecure by default if there is no option other than WithDefaults.
+	if custom == 0 {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 	log.Context(ctx).Infof("dialing to address: %s", address)
@@ -98,6 +119,13 @@ func DialContext(ctx context.Context, address string, options ...grpc.DialOption
 	return conn, nil
 }
 
+// WithRegistry return a dial option that resolves targets of the micro scheme
+// using the given registry, i.e: Dial("micro:///orders", WithRegistry(r)).
+// Without this option, the default registry is used, see registry.SetDefault.
+func WithRegistry(r registry.Registry) grpc.DialOption {
+	return grpc.WithResolvers(registry.NewResolverBuilder(r))
+}
+
 // Must return the given client connection if err is nil, otherwise panic.
 func Must(conn *grpc.ClientConn, err error) *grpc.ClientConn {
 	if err != nil {
@@ -121,12 +149,45 @@ func WithTLSTransportCredentials(certFile string) grpc.DialOption {
 	return grpc.WithTransportCredentials(opt)
 }
 
+// WithMutualTLS return new dial option using mutual TLS, the server is verified using
+// the CAs in caFile and the client presents the certificate in certFile and keyFile.
+// panic if the given files are not found or invalid.
+func WithMutualTLS(caFile, certFile, keyFile string) grpc.DialOption {
+	b, err := os.ReadFile(caFile)
+	if err != nil {
+		panic(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		panic(fmt.Sprintf("client: no valid certificate found in %s", caFile))
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		panic(err)
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+	}))
+}
+
+// WithOpenTelemetry return a dial option that enables OpenTelemetry tracing and metrics
+// for both unary and stream requests. The span context is propagated to the server
+// using W3C Trace Context by default.
+func WithOpenTelemetry(opts ...tracing.Option) grpc.DialOption {
+	return grpc.WithStatsHandler(tracing.ClientHandler(opts...))
+}
+
 // WithTracing return unary tracing interceptor dial option.
+//
+// Deprecated: OpenTracing is deprecated, use WithOpenTelemetry instead.
 func WithTracing(tracer opentracing.Tracer) grpc.DialOption {
 	return grpc.WithUnaryInterceptor(otgrpc.OpenTracingClientInterceptor(tracer))
 }
 
 // WithStreamTracing return stream tracing interceptor dial option.
+//
+// Deprecated: OpenTracing is deprecated, use WithOpenTelemetry instead.
 func WithStreamTracing(tracer opentracing.Tracer) grpc.DialOption {
 	return grpc.WithStreamInterceptor(otgrpc.OpenTracingStreamClientInterceptor(tracer))
 }
@@ -167,7 +228,7 @@ func NewContext(ctx context.Context, kv ...string) context.Context {
 // it also copies all associated metadata in the incoming/outcoming context to the new context.
 // If the given correlationID is empty, a new correlation id will be generated.
 //
-// NOTE: that this function has nothing to do with tracing using opentracing.
+// NOTE: that this function has nothing to do with distributed tracing, see WithOpenTelemetry.
 func NewTracingContext(ctx context.Context, correlationID string) context.Context {
 	if correlationID == "" {
 		correlationID = uuid.NewString()
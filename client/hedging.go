@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type (
+	// HedgingOption is an option to configure hedging interceptor.
+	HedgingOption func(*hedging)
+
+	hedging struct {
+		delay   time.Duration
+		max     int
+		methods map[string]bool
+		codes   map[codes.Code]bool
+		metrics *Metrics
+	}
+
+	hedgeResult struct {
+		reply   proto.Message
+		header  metadata.MD
+		trailer metadata.MD
+		err     error
+	}
+)
+
+// HedgingMethods is an option to set the full method names to be hedged, i.e: /helloworld.Greeter/SayHello.
+// By default, all methods are hedged.
+func HedgingMethods(methods ...string) HedgingOption {
+	return func(h *hedging) {
+		for _, m := range methods {
+			h.methods[m] = true
+		}
+	}
+}
+
+// HedgingMetrics is an option to set the metrics of hedged requests. Default is DefaultMetrics.
+func HedgingMetrics(m *Metrics) HedgingOption {
+	return func(h *hedging) {
+		h.metrics = m
+	}
+}
+
+// HedgingUnaryInterceptor return a grpc.UnaryClientInterceptor that sends an additional request
+// if there is no response after the given delay, up to max requests in total.
+// The first successful response is used and the other requests are canceled.
+// Only idempotent methods should be hedged, see HedgingMethods.
+func HedgingUnaryInterceptor(delay time.Duration, max int, opts ...HedgingOption) grpc.UnaryClientInterceptor {
+	h := &hedging{
+		delay:   delay,
+		max:     max,
+		methods: make(map[string]bool),
+		codes:   codeSet(defaultRetryCodes),
+		metrics: DefaultMetrics,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h.intercept
+}
+
+// WithHedging return a dial option that hedges unary requests. See HedgingUnaryInterceptor.
+func WithHedging(delay time.Duration, max int, opts ...HedgingOption) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(HedgingUnaryInterceptor(delay, max, opts...))
+}
+
+func (h *hedging) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	msg, ok := reply.(proto.Message)
+	if h.max <= 1 || !ok || (len(h.methods) > 0 && !h.methods[method]) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	// header and trailer are captured per request and copied from the winner only.
+	callOpts, header, trailer := splitMetadataOptions(opts)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan hedgeResult, h.max)
+	call := func() {
+		rs := hedgeResult{reply: msg.ProtoReflect().New().Interface()}
+		rs.err = invoker(ctx, method, req, rs.reply, cc, append(callOpts, grpc.Header(&rs.header), grpc.Trailer(&rs.trailer))...)
+		results <- rs
+	}
+	go call()
+	sent := 1
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+	var last hedgeResult
+	for received := 0; received < sent; {
+		select {
+		case <-timer.C:
+			if sent < h.max {
+				sent++
+				h.metrics.hedges.WithLabelValues(method).Inc()
+				go call()
+				timer.Reset(h.delay)
+			}
+		case rs := <-results:
+			received++
+			last = rs
+			if rs.err == nil {
+				proto.Reset(msg)
+				proto.Merge(msg, rs.reply)
+			}
+			if rs.err == nil || !h.codes[status.Code(rs.err)] {
+				setMetadata(header, rs.header, trailer, rs.trailer)
+				return rs.err
+			}
+			// failed fast, hedge immediately instead of waiting for the delay.
+			if sent < h.max && ctx.Err() == nil {
+				sent++
+				h.metrics.hedges.WithLabelValues(method).Inc()
+				go call()
+				timer.Reset(h.delay)
+			}
+		}
+	}
+	setMetadata(header, last.header, trailer, last.trailer)
+	return last.err
+}
+
+func splitMetadataOptions(opts []grpc.CallOption) ([]grpc.CallOption, []*metadata.MD, []*metadata.MD) {
+	rs := make([]grpc.CallOption, 0, len(opts))
+	header, trailer := make([]*metadata.MD, 0), make([]*metadata.MD, 0)
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			header = append(header, o.HeaderAddr)
+		case grpc.TrailerCallOption:
+			trailer = append(trailer, o.TrailerAddr)
+		default:
+			rs = append(rs, opt)
+		}
+	}
+	return rs, header, trailer
+}
+
+func setMetadata(header []*metadata.MD, h metadata.MD, trailer []*metadata.MD, t metadata.MD) {
+	for _, addr := range header {
+		*addr = h
+	}
+	for _, addr := range trailer {
+		*addr = t
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/util/contextutil"
+)
+
+type (
+	defaultsOption struct {
+		grpc.EmptyDialOption
+	}
+)
+
+// CorrelationIDUnaryInterceptor returns a grpc.UnaryClientInterceptor that propagates
+// correlation_id to the outgoing metadata. The correlation_id is taken from the outgoing
+// or incoming context, i.e: when calling other services while serving a request.
+// If no value is provided, a new UUID will be generated.
+func CorrelationIDUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withCorrelationID(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// CorrelationIDStreamInterceptor returns a grpc.StreamClientInterceptor that propagates
+// correlation_id to the outgoing metadata. See CorrelationIDUnaryInterceptor.
+func CorrelationIDStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withCorrelationID(ctx), desc, cc, method, opts...)
+	}
+}
+
+// LogUnaryInterceptor returns a grpc.UnaryClientInterceptor that logs
+// the calls using the context logger, see log.Context.
+func LogUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		bg := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		logCall(ctx, "call finished", cc.Target(), method, bg, err)
+		return err
+	}
+}
+
+// LogStreamInterceptor returns a grpc.StreamClientInterceptor that logs
+// the stream creations using the context logger, see log.Context.
+func LogStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		bg := time.Now()
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		logCall(ctx, "stream opened", cc.Target(), method, bg, err)
+		return s, err
+	}
+}
+
+// WithDefaults return a dial option that enables the default interceptors for both unary and stream calls:
+// correlation_id propagation, logging and Prometheus metrics.
+// The option takes effect only when using with Dial or DialContext of this package,
+// it's ignored by grpc.Dial and grpc.DialContext.
+func WithDefaults() grpc.DialOption {
+	return defaultsOption{}
+}
+
+// defaultDialOptions return dial options of the default interceptors.
+func defaultDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(
+			CorrelationIDUnaryInterceptor(),
+			LogUnaryInterceptor(),
+			grpc_prometheus.UnaryClientInterceptor,
+		),
+		grpc.WithChainStreamInterceptor(
+			CorrelationIDStreamInterceptor(),
+			LogStreamInterceptor(),
+			grpc_prometheus.StreamClientInterceptor,
+		),
+	}
+}
+
+func withCorrelationID(ctx context.Context) context.Context {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if len(md.Get(contextutil.XCorrelationID)) > 0 || len(md.Get(contextutil.XRequestID)) > 0 {
+			return ctx
+		}
+	}
+	id, _ := contextutil.CorrelationIDFromContext(ctx)
+	return metadata.AppendToOutgoingContext(ctx, contextutil.XCorrelationID, id)
+}
+
+func logCall(ctx context.Context, msg string, target, method string, bg time.Time, err error) {
+	logger := log.Context(ctx).Fields("target", target, "method", method, "duration", time.Since(bg), "code", status.Code(err).String())
+	if err != nil {
+		logger.Fields("error", err).Error(msg)
+		return
+	}
+	logger.Debug(msg)
+}
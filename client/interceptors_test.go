@@ -0,0 +1,68 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/pthethanh/micro/client"
+	"github.com/pthethanh/micro/util/contextutil"
+)
+
+func TestWithDefaults(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	ids := make(chan string, 1)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ids <- md.Get(contextutil.XCorrelationID)[0]
+		return handler(ctx, req)
+	}))
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := client.Dial("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		client.WithDefaults(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := grpc_health_v1.NewHealthClient(conn)
+
+	// propagate from incoming context.
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(contextutil.XCorrelationID, "123"))
+	if _, err := c.Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if id := <-ids; id != "123" {
+		t.Errorf("got correlation_id=%s, want correlation_id=123", id)
+	}
+	// generate if not provided.
+	if _, err := c.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if id := <-ids; id == "" {
+		t.Errorf("got correlation_id empty, want correlation_id generated")
+	}
+}
+
+func TestWithDefaultsOnly(t *testing.T) {
+	// insecure credentials are used by default as WithDefaults is not a transport option.
+	conn, err := client.Dial("localhost:8000", client.WithDefaults())
+	if err != nil {
+		t.Fatalf("got err=%v, want err=nil", err)
+	}
+	conn.Close()
+}
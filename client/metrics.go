@@ -0,0 +1,73 @@
+package client
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// Metrics holds Prometheus collectors of the client resilience interceptors.
+	Metrics struct {
+		retries       *prometheus.CounterVec
+		hedges        *prometheus.CounterVec
+		breakerState  *prometheus.GaugeVec
+		breakerReject *prometheus.CounterVec
+	}
+)
+
+var (
+	_ prometheus.Collector = (*Metrics)(nil)
+
+	// DefaultMetrics is the default metrics used by the client interceptors.
+	// It is registered automatically to the default Prometheus registry by the server
+	// when metrics are enabled, otherwise register it via Register.
+	DefaultMetrics = NewMetrics()
+)
+
+// NewMetrics return new client metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_retries_total",
+			Help: "Total number of retried requests by the code of the previous attempt.",
+		}, []string{"grpc_method", "grpc_code"}),
+		hedges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_hedges_total",
+			Help: "Total number of hedged requests.",
+		}, []string{"grpc_method"}),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_client_circuit_breaker_state",
+			Help: "State of the circuit breakers: 0 closed, 1 half-open, 2 open.",
+		}, []string{"target"}),
+		breakerReject: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_client_circuit_breaker_rejected_total",
+			Help: "Total number of requests rejected by open circuit breakers.",
+		}, []string{"target", "grpc_method"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.retries.Describe(ch)
+	m.hedges.Describe(ch)
+	m.breakerState.Describe(ch)
+	m.breakerReject.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.retries.Collect(ch)
+	m.hedges.Collect(ch)
+	m.breakerState.Collect(ch)
+	m.breakerReject.Collect(ch)
+}
+
+// Register registers the metrics to the given registerer.
+// It's safe to call Register multiple times.
+func (m *Metrics) Register(r prometheus.Registerer) error {
+	if err := r.Register(m); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package client_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/pthethanh/micro/client"
+)
+
+func newConn(t *testing.T) *grpc.ClientConn {
+	conn, err := grpc.Dial("passthrough:///test", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestRetry(t *testing.T) {
+	conn := newConn(t)
+	cases := []struct {
+		name  string
+		errs  []codes.Code
+		calls int32
+		code  codes.Code
+	}{
+		{name: "success", errs: []codes.Code{codes.OK}, calls: 1, code: codes.OK},
+		{name: "retried", errs: []codes.Code{codes.Unavailable, codes.Unavailable, codes.OK}, calls: 3, code: codes.OK},
+		{name: "not retryable", errs: []codes.Code{codes.InvalidArgument}, calls: 1, code: codes.InvalidArgument},
+		{name: "exhausted", errs: []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, calls: 3, code: codes.Unavailable},
+	}
+	f := client.RetryUnaryInterceptor(client.RetryMax(2), client.RetryBackoff(time.Millisecond, 5*time.Millisecond))
+	for _, c := range cases {
+		calls := int32(0)
+		err := f(context.Background(), "/test/Test", nil, nil, conn, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			i := atomic.AddInt32(&calls, 1) - 1
+			return status.Error(c.errs[i], "error")
+		})
+		if calls != c.calls || status.Code(err) != c.code {
+			t.Errorf("%s: got calls=%d, code=%v, want calls=%d, code=%v", c.name, calls, status.Code(err), c.calls, c.code)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	conn := newConn(t)
+	f := client.RetryUnaryInterceptor(client.RetryMax(1), client.RetryBackoff(time.Millisecond, time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	calls := 0
+	err := f(ctx, "/test/Test", nil, nil, conn, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		for _, opt := range opts {
+			if h, ok := opt.(grpc.HeaderCallOption); ok {
+				*h.HeaderAddr = metadata.Pairs("retry-after", "1")
+			}
+		}
+		return status.Error(codes.ResourceExhausted, "rate limited")
+	})
+	// retry after 1s exceeds the deadline, no more retries.
+	if calls != 1 || status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("got calls=%d, code=%v, want calls=1, code=%v", calls, status.Code(err), codes.ResourceExhausted)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	conn := newConn(t)
+	f := client.CircuitBreakerUnaryInterceptor(client.BreakerThreshold(2), client.BreakerOpenTimeout(50*time.Millisecond))
+	var code codes.Code
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(code, "error")
+	}
+	code = codes.Unavailable
+	for i := 0; i < 3; i++ {
+		f(context.Background(), "/test/Test", nil, nil, conn, invoker)
+	}
+	if calls != 2 {
+		t.Fatalf("got calls=%d, want calls=2 as circuit is open", calls)
+	}
+	time.Sleep(60 * time.Millisecond)
+	code = codes.OK
+	if err := f(context.Background(), "/test/Test", nil, nil, conn, invoker); err != nil {
+		t.Fatalf("got err=%v, want trial request succeeded", err)
+	}
+	if err := f(context.Background(), "/test/Test", nil, nil, conn, invoker); err != nil || calls != 4 {
+		t.Fatalf("got err=%v, calls=%d, want circuit closed", err, calls)
+	}
+}
+
+func TestHedging(t *testing.T) {
+	conn := newConn(t)
+	metrics := client.NewMetrics()
+	f := client.HedgingUnaryInterceptor(10*time.Millisecond, 3, client.HedgingMetrics(metrics))
+	calls := int32(0)
+	reply := &grpc_health_v1.HealthCheckResponse{}
+	err := f(context.Background(), "/test/Test", nil, reply, conn, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		// the first request hangs, the hedged one wins.
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.(*grpc_health_v1.HealthCheckResponse).Status = grpc_health_v1.HealthCheckResponse_SERVING
+		return nil
+	})
+	if err != nil || reply.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("got err=%v, status=%v, want hedged response", err, reply.Status)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("got calls=%d, want calls=2", got)
+	}
+	want := `
+# HELP grpc_client_hedges_total Total number of hedged requests.
+# TYPE grpc_client_hedges_total counter
+grpc_client_hedges_total{grpc_method="/test/Test"} 1
+`
+	if err := testutil.CollectAndCompare(metrics, strings.NewReader(want), "grpc_client_hedges_total"); err != nil {
+		t.Fatal(err)
+	}
+}
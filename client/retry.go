@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/pthethanh/micro/ratelimit"
+)
+
+type (
+	// RetryOption is an option to configure retry interceptor.
+	RetryOption func(*retry)
+
+	retry struct {
+		max        int
+		base       time.Duration
+		maxBackoff time.Duration
+		perAttempt time.Duration
+		codes      map[codes.Code]bool
+		metrics    *Metrics
+	}
+)
+
+var (
+	defaultRetryCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted}
+)
+
+// RetryMax is an option to set maximum number of retries. Default is 3.
+func RetryMax(n int) RetryOption {
+	return func(r *retry) {
+		r.max = n
+	}
+}
+
+// RetryBackoff is an option to set base and max backoff between retries.
+// The backoff is exponential with full jitter: random(0, min(max, base * 2^attempt)).
+// Default is 100ms and 5s.
+func RetryBackoff(base, max time.Duration) RetryOption {
+	return func(r *retry) {
+		r.base = base
+		r.maxBackoff = max
+	}
+}
+
+// RetryCodes is an option to set the codes that are retried.
+// Default is codes.Unavailable and codes.ResourceExhausted.
+func RetryCodes(cs ...codes.Code) RetryOption {
+	return func(r *retry) {
+		r.codes = codeSet(cs)
+	}
+}
+
+// RetryPerAttemptTimeout is an option to set timeout of each attempt.
+// Attempts timed out are retried as long as the parent context is not done.
+func RetryPerAttemptTimeout(d time.Duration) RetryOption {
+	return func(r *retry) {
+		r.perAttempt = d
+	}
+}
+
+// RetryMetrics is an option to set the metrics of retried requests. Default is DefaultMetrics.
+func RetryMetrics(m *Metrics) RetryOption {
+	return func(r *retry) {
+		r.metrics = m
+	}
+}
+
+// RetryUnaryInterceptor return a grpc.UnaryClientInterceptor that retries failed requests
+// having retryable codes with exponential backoff and jitter. If the server responds with
+// retry-after header, i.e: rate limited, the client waits at least the given duration.
+// Only idempotent methods should be retried.
+func RetryUnaryInterceptor(opts ...RetryOption) grpc.UnaryClientInterceptor {
+	r := &retry{
+		max:        3,
+		base:       100 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+		codes:      codeSet(defaultRetryCodes),
+		metrics:    DefaultMetrics,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r.intercept
+}
+
+// WithRetry return a dial option that retries failed unary requests. See RetryUnaryInterceptor.
+func WithRetry(opts ...RetryOption) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(RetryUnaryInterceptor(opts...))
+}
+
+func (r *retry) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	for attempt := 0; ; attempt++ {
+		var header, trailer metadata.MD
+		err := r.invoke(ctx, method, req, reply, cc, invoker, append(opts, grpc.Header(&header), grpc.Trailer(&trailer))...)
+		if err == nil || attempt >= r.max || ctx.Err() != nil {
+			return err
+		}
+		code := status.Code(err)
+		if !r.codes[code] && !(code == codes.DeadlineExceeded && r.perAttempt > 0) {
+			return err
+		}
+		wait := backoff(r.base, r.maxBackoff, attempt)
+		if ra, ok := retryAfter(header, trailer); ok && ra > wait {
+			wait = ra
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		r.metrics.retries.WithLabelValues(method, code.String()).Inc()
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+func (r *retry) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if r.perAttempt <= 0 {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.perAttempt)
+	defer cancel()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// backoff return exponential backoff with full jitter of the given attempt.
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	d := max
+	if attempt < 32 {
+		if exp := base << uint(attempt); exp > 0 && exp < max {
+			d = exp
+		}
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// retryAfter return duration of the retry-after header in seconds if provided.
+func retryAfter(mds ...metadata.MD) (time.Duration, bool) {
+	for _, md := range mds {
+		if v := md.Get(ratelimit.RetryAfterMD); len(v) > 0 {
+			if s, err := strconv.Atoi(v[0]); err == nil && s >= 0 {
+				return time.Duration(s) * time.Second, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func codeSet(cs []codes.Code) map[codes.Code]bool {
+	m := make(map[codes.Code]bool, len(cs))
+	for _, c := range cs {
+		m[c] = true
+	}
+	return m
+}
@@ -1,7 +1,11 @@
 package micro
 
 import (
+	"fmt"
+
+	"github.com/pthethanh/micro/cache/cachepb"
 	"github.com/pthethanh/micro/cmd/protoc-gen-micro/internal/generator"
+	"google.golang.org/protobuf/proto"
 	pb "google.golang.org/protobuf/types/descriptorpb"
 )
 
@@ -50,6 +54,9 @@ func (g *micro) GenerateImports(file *generator.FileDescriptor, imports map[gene
 		g.P("grpc ", `"google.golang.org/grpc"`)
 		g.P(`"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"`)
 	}
+	if hasCacheOptions(file) {
+		g.P(`"time"`)
+	}
 	g.P(")")
 	g.P()
 }
@@ -72,4 +79,54 @@ func (g *micro) generateService(file *generator.FileDescriptor, service *pb.Serv
 		g.P("Register" + serviceName + "HandlerFromEndpoint(ctx, mux, endpoint, opts)")
 		g.P("}")
 	}
+
+	g.generateCacheTTL(file, service, serviceAlias)
+}
+
+// generateCacheTTL generates CacheTTL method for the service
+// if any of its methods has cache option.
+func (g *micro) generateCacheTTL(file *generator.FileDescriptor, service *pb.ServiceDescriptorProto, serviceAlias string) {
+	methods := make([]*pb.MethodDescriptorProto, 0)
+	for _, method := range service.Method {
+		if cacheOptions(method) != nil {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return
+	}
+	fullServiceName := service.GetName()
+	if pkg := file.GetPackage(); pkg != "" {
+		fullServiceName = pkg + "." + fullServiceName
+	}
+	g.P()
+	g.P("// CacheTTL returns TTL of cached responses of the given method.")
+	g.P("func (", serviceAlias, ") CacheTTL(method string) (time.Duration, bool) {")
+	g.P("switch method {")
+	for _, method := range methods {
+		g.P(fmt.Sprintf("case %q:", "/"+fullServiceName+"/"+method.GetName()))
+		g.P(fmt.Sprintf("return %d * time.Second, true", cacheOptions(method).GetTtlSeconds()))
+	}
+	g.P("}")
+	g.P("return 0, false")
+	g.P("}")
+}
+
+func hasCacheOptions(file *generator.FileDescriptor) bool {
+	for _, service := range file.FileDescriptorProto.Service {
+		for _, method := range service.Method {
+			if cacheOptions(method) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cacheOptions(method *pb.MethodDescriptorProto) *cachepb.CacheOptions {
+	if method.GetOptions() == nil || !proto.HasExtension(method.GetOptions(), cachepb.E_Cache) {
+		return nil
+	}
+	opts, _ := proto.GetExtension(method.GetOptions(), cachepb.E_Cache).(*cachepb.CacheOptions)
+	return opts
 }
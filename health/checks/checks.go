@@ -0,0 +1,176 @@
+// Package checks provides health checkers for common dependencies.
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/pthethanh/micro/health"
+)
+
+type (
+	// Option is an option to configure the checkers.
+	Option func(*options)
+
+	// Pinger is implemented by *sql.DB and *sql.Conn.
+	Pinger interface {
+		PingContext(ctx context.Context) error
+	}
+
+	options struct {
+		timeout time.Duration
+		client  *http.Client
+		status  []int
+	}
+)
+
+const (
+	// DefaultTimeout is the timeout applied to the checks if the context has no deadline.
+	DefaultTimeout = 5 * time.Second
+)
+
+// Timeout is an option to set timeout of the checks if the context has no deadline.
+// Default is DefaultTimeout.
+func Timeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.timeout = d
+	}
+}
+
+// HTTPClient is an option to set the client used by the HTTP checker.
+func HTTPClient(c *http.Client) Option {
+	return func(opts *options) {
+		opts.client = c
+	}
+}
+
+// HTTPStatus is an option to set the expected status codes of the HTTP checker.
+// Default is any 2xx status code.
+func HTTPStatus(codes ...int) Option {
+	return func(opts *options) {
+		opts.status = codes
+	}
+}
+
+// SQL return a checker that pings the database, i.e: *sql.DB.
+func SQL(db Pinger, opts ...Option) health.Checker {
+	o := newOptions(opts...)
+	return health.CheckFunc(func(ctx context.Context) error {
+		ctx, cancel := o.context(ctx)
+		defer cancel()
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("checks: sql ping, err: %w", err)
+		}
+		return nil
+	})
+}
+
+// HTTP return a checker that sends a GET request to the url and expects a 2xx status code.
+func HTTP(url string, opts ...Option) health.Checker {
+	o := newOptions(opts...)
+	return health.CheckFunc(func(ctx context.Context) error {
+		ctx, cancel := o.context(ctx)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("checks: http GET %s, err: %w", url, err)
+		}
+		res, err := o.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("checks: http GET %s, err: %w", url, err)
+		}
+		defer res.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+		if !o.isExpected(res.StatusCode) {
+			return fmt.Errorf("checks: http GET %s, unexpected status: %s", url, res.Status)
+		}
+		return nil
+	})
+}
+
+// GRPC return a checker that checks status of the service using the gRPC health checking protocol
+// via the given connection, i.e: a connection created by client.Dial.
+// Use empty service name for checking the overall status of the server.
+func GRPC(conn grpc.ClientConnInterface, service string, opts ...Option) health.Checker {
+	o := newOptions(opts...)
+	c := health.NewClient(conn)
+	return health.CheckFunc(func(ctx context.Context) error {
+		ctx, cancel := o.context(ctx)
+		defer cancel()
+		res, err := c.Check(ctx, &health.CheckRequest{Service: service})
+		if err != nil {
+			return fmt.Errorf("checks: grpc health check, service: %q, err: %w", service, err)
+		}
+		if res.Status != health.StatusServing {
+			return fmt.Errorf("checks: grpc health check, service: %q, status: %s", service, res.Status)
+		}
+		return nil
+	})
+}
+
+// TCP return a checker that dials to the address.
+func TCP(addr string, opts ...Option) health.Checker {
+	o := newOptions(opts...)
+	return health.CheckFunc(func(ctx context.Context) error {
+		ctx, cancel := o.context(ctx)
+		defer cancel()
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("checks: tcp dial %s, err: %w", addr, err)
+		}
+		return conn.Close()
+	})
+}
+
+// DiskSpace return a checker that fails if free space of the file system
+// containing the path is less than minFree bytes.
+func DiskSpace(path string, minFree uint64) health.Checker {
+	return health.CheckFunc(func(ctx context.Context) error {
+		free, err := freeSpace(path)
+		if err != nil {
+			return fmt.Errorf("checks: disk space %s, err: %w", path, err)
+		}
+		if free < minFree {
+			return fmt.Errorf("checks: disk space %s, free: %d bytes, want at least: %d bytes", path, free, minFree)
+		}
+		return nil
+	})
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		timeout: DefaultTimeout,
+		client:  http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// context return a context with the timeout if the given context has no deadline.
+func (o *options) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || o.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+func (o *options) isExpected(code int) bool {
+	if len(o.status) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, c := range o.status {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package checks_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/pthethanh/micro/health"
+	"github.com/pthethanh/micro/health/checks"
+)
+
+type pinger func(ctx context.Context) error
+
+func (p pinger) PingContext(ctx context.Context) error {
+	return p(ctx)
+}
+
+func TestSQL(t *testing.T) {
+	if err := checks.SQL(pinger(func(ctx context.Context) error { return nil })).CheckHealth(context.Background()); err != nil {
+		t.Errorf("got err=%v, want err=nil", err)
+	}
+	down := errors.New("connection refused")
+	if err := checks.SQL(pinger(func(ctx context.Context) error { return down })).CheckHealth(context.Background()); !errors.Is(err, down) {
+		t.Errorf("got err=%v, want err=%v", err, down)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	if err := checks.HTTP(srv.URL + "/up").CheckHealth(context.Background()); err != nil {
+		t.Errorf("got err=%v, want err=nil", err)
+	}
+	if err := checks.HTTP(srv.URL + "/down").CheckHealth(context.Background()); err == nil {
+		t.Errorf("got err=nil, want err for status 503")
+	}
+	if err := checks.HTTP(srv.URL+"/down", checks.HTTPStatus(http.StatusServiceUnavailable)).CheckHealth(context.Background()); err != nil {
+		t.Errorf("got err=%v, want err=nil for expected status", err)
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	hsrv := health.NewServer(map[string]health.Checker{})
+	hsrv.Init(health.StatusServing)
+	defer hsrv.Close()
+	hsrv.SetStatus("orders", health.StatusNotServing)
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	hsrv.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := checks.GRPC(conn, "").CheckHealth(context.Background()); err != nil {
+		t.Errorf("got err=%v, want err=nil", err)
+	}
+	if err := checks.GRPC(conn, "orders").CheckHealth(context.Background()); err == nil {
+		t.Errorf("got err=nil, want err for NOT_SERVING service")
+	}
+}
+
+func TestTCP(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	if err := checks.TCP(addr).CheckHealth(context.Background()); err != nil {
+		t.Errorf("got err=%v, want err=nil", err)
+	}
+	lis.Close()
+	if err := checks.TCP(addr).CheckHealth(context.Background()); err == nil {
+		t.Errorf("got err=nil, want err for closed listener")
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := checks.DiskSpace(dir, 0).CheckHealth(context.Background()); err != nil {
+		t.Skipf("disk space is not supported, err: %v", err)
+	}
+	if err := checks.DiskSpace(dir, math.MaxUint64).CheckHealth(context.Background()); err == nil {
+		t.Errorf("got err=nil, want err for insufficient space")
+	}
+}
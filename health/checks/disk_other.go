@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package checks
+
+import (
+	"errors"
+)
+
+func freeSpace(path string) (uint64, error) {
+	return 0, errors.New("not supported on this platform")
+}
@@ -0,0 +1,124 @@
+package health
+
+import (
+	"time"
+)
+
+type (
+	// Tag defines which probes a checker takes part in.
+	Tag string
+
+	// CheckerOption is an option to configure a checker.
+	CheckerOption func(*checker)
+
+	// StartupMarker is implemented by the health check servers supporting startup probe.
+	StartupMarker interface {
+		// MarkStarted marks that the application finished its startup.
+		MarkStarted()
+	}
+
+	checker struct {
+		Checker
+		tags             []Tag
+		failureThreshold int
+		interval         time.Duration
+		timeout          time.Duration
+		critical         bool
+		next             time.Time
+	}
+)
+
+// Tags of the checkers.
+const (
+	// TagReadiness marks that failures of the checker fail the readiness probe.
+	// Checkers without tags are tagged as readiness only.
+	TagReadiness Tag = "readiness"
+	// TagLiveness marks that failures of the checker fail the liveness probe,
+	// it should be used only for the failures that can be recovered by a restart.
+	TagLiveness Tag = "liveness"
+)
+
+// Probe names, used as the last segment of the HTTP health check paths,
+// i.e: /internal/health/live.
+const (
+	LivenessProbe  = "live"
+	ReadinessProbe = "ready"
+	StartupProbe   = "startup"
+)
+
+var (
+	_ StartupMarker = &MServer{}
+)
+
+// WithOptions return a checker configured with the given options.
+func WithOptions(c Checker, opts ...CheckerOption) Checker {
+	ck := newChecker(c)
+	for _, opt := range opts {
+		opt(ck)
+	}
+	return ck
+}
+
+// Tags is an option to set the tags of the checker. Default is TagReadiness.
+func Tags(tags ...Tag) CheckerOption {
+	return func(c *checker) {
+		c.tags = tags
+	}
+}
+
+// CheckerFailureThreshold is an option to set number of consecutive failures of the checker
+// before its service is flipped to NOT_SERVING, it overrides the FailureThreshold of the server.
+func CheckerFailureThreshold(n int) CheckerOption {
+	return func(c *checker) {
+		c.failureThreshold = n
+	}
+}
+
+// CheckerInterval is an option to set interval of the checker, it overrides the Interval of the server.
+func CheckerInterval(d time.Duration) CheckerOption {
+	return func(c *checker) {
+		c.interval = d
+	}
+}
+
+// CheckerTimeout is an option to set timeout of the checker, it overrides the Timeout of the server.
+func CheckerTimeout(d time.Duration) CheckerOption {
+	return func(c *checker) {
+		c.timeout = d
+	}
+}
+
+// NonCritical is an option to mark the checker as non-critical. Failures of a non-critical
+// checker only degrade the server: its service is NOT_SERVING, but the overall status
+// and the readiness probe are not affected.
+func NonCritical() CheckerOption {
+	return func(c *checker) {
+		c.critical = false
+	}
+}
+
+// Liveness return a checker which is critical for both liveness and readiness.
+func Liveness(c Checker) Checker {
+	return WithOptions(c, Tags(TagLiveness, TagReadiness))
+}
+
+func newChecker(c Checker) *checker {
+	if ck, ok := c.(*checker); ok {
+		cp := *ck
+		return &cp
+	}
+	return &checker{
+		Checker:  c,
+		tags:     []Tag{TagReadiness},
+		critical: true,
+	}
+}
+
+func (c *checker) hasTag(tag Tag) bool {
+	for _, t := range c.tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
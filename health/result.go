@@ -0,0 +1,128 @@
+package health
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// Result is the result of the latest health checks of a service.
+	Result struct {
+		// Status is the serving status of the service, taking failure threshold into account.
+		Status Status
+		// Error is the error message of the latest check, empty if it succeeded.
+		Error string
+		// LastCheck is the time of the latest check.
+		LastCheck time.Time
+		// LastSuccess is the time of the latest successful check.
+		LastSuccess time.Time
+		// Duration is the duration of the latest check.
+		Duration time.Duration
+		// ConsecutiveFailures is the number of consecutive failed checks.
+		ConsecutiveFailures int
+	}
+)
+
+var (
+	statusDesc = prometheus.NewDesc(
+		"health_check_status",
+		"Serving status of the service, 1 if SERVING, 0 otherwise.",
+		[]string{"service"}, nil,
+	)
+	durationDesc = prometheus.NewDesc(
+		"health_check_duration_seconds",
+		"Duration of the latest health check of the service.",
+		[]string{"service"}, nil,
+	)
+	failuresDesc = prometheus.NewDesc(
+		"health_check_consecutive_failures",
+		"Number of consecutive failed health checks of the service.",
+		[]string{"service"}, nil,
+	)
+	lastSuccessDesc = prometheus.NewDesc(
+		"health_check_last_success_timestamp_seconds",
+		"Unix time of the latest successful health check of the service.",
+		[]string{"service"}, nil,
+	)
+
+	_ prometheus.Collector = &MServer{}
+)
+
+// MarshalJSON implements json.Marshaler.
+func (r Result) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Status              Status     `json:"status"`
+		Error               string     `json:"error,omitempty"`
+		LastCheck           time.Time  `json:"last_check"`
+		LastSuccess         *time.Time `json:"last_success,omitempty"`
+		Duration            string     `json:"duration"`
+		ConsecutiveFailures int        `json:"consecutive_failures"`
+	}{
+		Status:              r.Status,
+		Error:               r.Error,
+		LastCheck:           r.LastCheck,
+		Duration:            r.Duration.String(),
+		ConsecutiveFailures: r.ConsecutiveFailures,
+	}
+	if !r.LastSuccess.IsZero() {
+		v.LastSuccess = &r.LastSuccess
+	}
+	return json.Marshal(v)
+}
+
+// record records the result of a check and return the serving status of the service,
+// which is flipped to NOT_SERVING only if the consecutive failures reach the threshold.
+func (r *Result) record(err error, at time.Time, d time.Duration, threshold int) Status {
+	r.LastCheck = at
+	r.Duration = d
+	if err == nil {
+		r.Error = ""
+		r.LastSuccess = at
+		r.ConsecutiveFailures = 0
+		r.Status = StatusServing
+		return r.Status
+	}
+	r.Error = err.Error()
+	r.ConsecutiveFailures++
+	if r.ConsecutiveFailures >= threshold || r.Status != StatusServing {
+		r.Status = StatusNotServing
+	}
+	return r.Status
+}
+
+// Results return the results of the latest health checks.
+func (s *MServer) Results() map[string]Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make(map[string]Result, len(s.results))
+	for name, r := range s.results {
+		results[name] = *r
+	}
+	return results
+}
+
+// Describe implements prometheus.Collector.
+func (s *MServer) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statusDesc
+	ch <- durationDesc
+	ch <- failuresDesc
+	ch <- lastSuccessDesc
+}
+
+// Collect implements prometheus.Collector.
+func (s *MServer) Collect(ch chan<- prometheus.Metric) {
+	for name, r := range s.Results() {
+		status := 0.0
+		if r.Status == StatusServing {
+			status = 1
+		}
+		ch <- prometheus.MustNewConstMetric(statusDesc, prometheus.GaugeValue, status, name)
+		ch <- prometheus.MustNewConstMetric(durationDesc, prometheus.GaugeValue, r.Duration.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(failuresDesc, prometheus.GaugeValue, float64(r.ConsecutiveFailures), name)
+		if !r.LastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, float64(r.LastSuccess.Unix()), name)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -23,18 +24,35 @@ import (
 type (
 	// MServer is a simple implementation of Server.
 	MServer struct {
-		checks map[string]Checker
-		ticker *time.Ticker
 		log    log.Logger
-
 		server *health.Server
 		conf   Config
+
+		mu      sync.RWMutex
+		checks  map[string]*checker
+		results map[string]*Result
+		tick    time.Duration
+		closed  bool
+		// ctx is canceled on Close, loopCancel stops the current run loop.
+		ctx        context.Context
+		cancel     context.CancelFunc
+		loopCancel context.CancelFunc
+		reset      chan struct{}
+		wg         sync.WaitGroup
+
+		initialized atomic.Bool
+		started     atomic.Bool
 	}
 
 	// Config hold server config.
 	Config struct {
 		Interval time.Duration `envconfig:"HEALTH_CHECK_INTERVAL" default:"5m"`
 		Timeout  time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"500ms"`
+		// FailureThreshold is number of consecutive failures before a service is flipped to NOT_SERVING.
+		FailureThreshold int `envconfig:"HEALTH_CHECK_FAILURE_THRESHOLD" default:"1"`
+		// Details enables details of the check results in the HTTP responses.
+		// It should be enabled only if the health check endpoint is not public as the errors might leak information.
+		Details bool `envconfig:"HEALTH_CHECK_DETAILS" default:"false"`
 	}
 
 	// ServerOption is a function to provide additional options for server.
@@ -100,8 +118,13 @@ var (
 // NewServer return new gRPC health server.
 func NewServer(m map[string]Checker, opts ...ServerOption) *MServer {
 	srv := &MServer{
-		checks: m,
-		server: health.NewServer(),
+		checks:  make(map[string]*checker),
+		server:  health.NewServer(),
+		results: make(map[string]*Result),
+		reset:   make(chan struct{}, 1),
+	}
+	for name, c := range m {
+		srv.checks[name] = newChecker(c)
 	}
 	for _, opt := range opts {
 		opt(srv)
@@ -116,8 +139,9 @@ func NewServer(m map[string]Checker, opts ...ServerOption) *MServer {
 	if srv.conf.Timeout == 0 {
 		srv.conf.Timeout = 500 * time.Millisecond
 	}
-	srv.ticker = time.NewTicker(srv.conf.Interval)
-
+	if srv.conf.FailureThreshold <= 0 {
+		srv.conf.FailureThreshold = 1
+	}
 	return srv
 }
 
@@ -128,55 +152,267 @@ func (s *MServer) Register(srv *grpc.Server) {
 
 // Init implements health.Server.
 func (s *MServer) Init(status Status) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.New("health: server closed")
+	}
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+	ctx := s.ctx
+	names := make([]string, 0, len(s.checks))
+	for name := range s.checks {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
 	s.server.SetServingStatus(OverallServiceName, status)
 	// if there is no dependent services, don't need to do anything else.
-	if len(s.checks) == 0 {
+	if len(names) == 0 {
+		s.initialized.Store(true)
 		return nil
 	}
 	// if there are dependent services, set overall status and all dependent services
 	// to NotServing as we don't know their status yet.
 	s.server.SetServingStatus(OverallServiceName, StatusNotServing)
-	for name := range s.checks {
+	for _, name := range names {
 		s.server.SetServingStatus(name, StatusNotServing)
 	}
 	// start a first check immediately.
-	s.checkAll()
+	s.checkAll(ctx, true)
+	s.initialized.Store(true)
 	// schedule the check
-	go func() {
-		for range s.ticker.C {
-			s.checkAll()
-		}
-	}()
+	s.mu.Lock()
+	s.startLoop()
+	s.mu.Unlock()
 	return nil
 }
 
-func (s *MServer) checkAll() {
+// AddChecker registers a checker of the service. If the server is initialized,
+// the service is checked immediately and then scheduled along with the other checkers.
+// It replaces the existing checker of the same service if any.
+func (s *MServer) AddChecker(service string, c Checker) {
+	check := newChecker(c)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.checks[service] = check
+	delete(s.results, service)
+	if s.ctx == nil {
+		return
+	}
+	s.server.SetServingStatus(service, StatusNotServing)
+	check.next = time.Now().Add(s.intervalOf(check))
+	s.wg.Add(1)
+	go func(ctx context.Context) {
+		defer s.wg.Done()
+		s.checkOne(ctx, service, check)
+		s.updateOverall()
+	}(s.ctx)
+	if !s.startLoop() {
+		s.resetLoop()
+	}
+}
+
+// RemoveChecker removes the checker of the service, status of the service is set to SERVICE_UNKNOWN.
+func (s *MServer) RemoveChecker(service string) {
+	s.mu.Lock()
+	if _, ok := s.checks[service]; !ok || s.closed {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.checks, service)
+	delete(s.results, service)
+	if len(s.checks) == 0 {
+		s.stopLoop()
+	} else {
+		s.resetLoop()
+	}
+	initialized := s.ctx != nil
+	s.mu.Unlock()
+	if initialized {
+		s.server.SetServingStatus(service, StatusServiceUnknown)
+		s.updateOverall()
+	}
+}
+
+// startLoop starts the run loop if it is not running and there are checks.
+// It must be called with s.mu held, return true if the loop is started.
+func (s *MServer) startLoop() bool {
+	if s.loopCancel != nil || s.closed || s.ctx == nil || len(s.checks) == 0 {
+		return false
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.loopCancel = cancel
+	s.tick = s.tickInterval()
+	ticker := time.NewTicker(s.tick)
+	s.wg.Add(1)
+	go s.run(ctx, ticker)
+	return true
+}
+
+// stopLoop stops the run loop, it must be called with s.mu held.
+func (s *MServer) stopLoop() {
+	if s.loopCancel != nil {
+		s.loopCancel()
+		s.loopCancel = nil
+	}
+}
+
+// resetLoop signals the run loop to recalculate the tick interval, it must be called with s.mu held.
+func (s *MServer) resetLoop() {
+	select {
+	case s.reset <- struct{}{}:
+	default:
+	}
+}
+
+// run checks the services when they are due until the context is canceled.
+func (s *MServer) run(ctx context.Context, ticker *time.Ticker) {
+	defer s.wg.Done()
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.reset:
+			s.mu.Lock()
+			s.tick = s.tickInterval()
+			ticker.Reset(s.tick)
+			s.mu.Unlock()
+		case <-ticker.C:
+			s.checkAll(ctx, false)
+		}
+	}
+}
+
+// tickInterval return the shortest interval of the checkers, it must be called with s.mu held.
+func (s *MServer) tickInterval() time.Duration {
+	interval := s.conf.Interval
+	for _, c := range s.checks {
+		if c.interval > 0 && c.interval < interval {
+			interval = c.interval
+		}
+	}
+	return interval
+}
+
+// checkAll checks the services which are due, or all of them if force is true,
+// and updates the overall status.
+func (s *MServer) checkAll(ctx context.Context, force bool) {
 	logger := s.log.Fields(log.CorrelationID, uuid.New().String())
 	bg := time.Now()
-	overall := int32(StatusServing)
-	wg := sync.WaitGroup{}
-	wg.Add(len(s.checks))
+	due := make(map[string]*checker)
+	s.mu.Lock()
 	for service, check := range s.checks {
-		go func(service string, check Checker) {
+		if !force && bg.Before(check.next) {
+			continue
+		}
+		// tolerate delays of the ticks.
+		check.next = bg.Add(s.intervalOf(check) - s.tick/2)
+		due[service] = check
+	}
+	s.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(len(due))
+	for service, check := range due {
+		go func(service string, check *checker) {
 			defer wg.Done()
-			status := StatusServing
-			if err := s.check(service, check); err != nil {
-				atomic.StoreInt32(&overall, int32(StatusNotServing))
-				status = StatusNotServing
+			if err := s.checkOne(ctx, service, check); err != nil {
 				logger.Infof("health check failed, service: %s, err: %v", service, err)
 			}
-			s.server.SetServingStatus(service, status)
 		}(service, check)
 	}
 	wg.Wait()
-	s.SetStatus(OverallServiceName, Status(overall))
+	overall := s.updateOverall()
 	logger.Fields("status", overall, "duration", time.Since(bg)).Info("health check completed")
 }
 
-func (s *MServer) check(service string, check Checker) error {
-	ctx, cancel := context.WithTimeout(context.Background(), s.conf.Timeout)
+// checkOne checks the service and records the result.
+func (s *MServer) checkOne(ctx context.Context, service string, check *checker) error {
+	at := time.Now()
+	err := s.check(ctx, check)
+	s.record(service, check, err, at, time.Since(at))
+	return err
+}
+
+// record records result of a check and updates status of the service.
+func (s *MServer) record(service string, check *checker, err error, at time.Time, d time.Duration) {
+	threshold := s.conf.FailureThreshold
+	if check.failureThreshold > 0 {
+		threshold = check.failureThreshold
+	}
+	s.mu.Lock()
+	// the checker is removed or replaced during the check.
+	if s.checks[service] != check {
+		s.mu.Unlock()
+		return
+	}
+	rs, ok := s.results[service]
+	if !ok {
+		rs = &Result{Status: StatusNotServing}
+		s.results[service] = rs
+	}
+	status := rs.record(err, at, d, threshold)
+	s.mu.Unlock()
+	s.server.SetServingStatus(service, status)
+}
+
+// updateOverall updates the overall status, which is NOT_SERVING if one of
+// the critical readiness checkers is not serving.
+func (s *MServer) updateOverall() Status {
+	overall, _ := s.readiness()
+	s.server.SetServingStatus(OverallServiceName, overall)
+	return overall
+}
+
+// readiness return status of the readiness and whether the server is degraded,
+// i.e: some of the non-critical checkers are not serving.
+func (s *MServer) readiness() (Status, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	overall, degraded := StatusServing, false
+	for service, c := range s.checks {
+		if !c.hasTag(TagReadiness) {
+			continue
+		}
+		status := StatusNotServing
+		if rs, ok := s.results[service]; ok {
+			status = rs.Status
+		}
+		if status == StatusServing {
+			continue
+		}
+		if c.critical {
+			overall = StatusNotServing
+		} else {
+			degraded = true
+		}
+	}
+	return overall, degraded
+}
+
+func (s *MServer) intervalOf(c *checker) time.Duration {
+	if c.interval > 0 {
+		return c.interval
+	}
+	return s.conf.Interval
+}
+
+func (s *MServer) check(ctx context.Context, check *checker) error {
+	timeout := s.conf.Timeout
+	if check.timeout > 0 {
+		timeout = check.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	ch := make(chan error)
+	// buffered so that the checker doesn't block forever if it exceeds the timeout.
+	ch := make(chan error, 1)
 	go func() {
 		ch <- check.CheckHealth(ctx)
 	}()
@@ -199,6 +435,9 @@ func (s *MServer) Watch(req *CheckRequest, srv WatchServer) error {
 }
 
 // ServeHTTP implements health.Server.
+// The probes are served at the paths ending with LivenessProbe, ReadinessProbe and StartupProbe,
+// other paths return the overall status or status of the service given in the query.
+// It responds 503 if the status is not SERVING.
 func (s *MServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	service := r.URL.Query().Get("service")
 	data := make(map[string]interface{})
@@ -216,44 +455,153 @@ func (s *MServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		return rs.Status
 	}
-	// overall - check all dependent services.
-	if service == OverallServiceName {
+	switch {
+	case path.Base(r.URL.Path) == LivenessProbe:
+		data["status"], data["services"] = s.liveness()
+	case path.Base(r.URL.Path) == StartupProbe:
+		data["status"] = s.startup()
+	case path.Base(r.URL.Path) == ReadinessProbe, service == OverallServiceName:
+		// overall - check all dependent services.
 		overall := check(OverallServiceName)
 		services := make(map[string]Status)
-		for service := range s.checks {
+		for service, c := range s.checkers() {
 			status := check(service)
 			services[service] = status
-			if status != StatusServing {
+			if status != StatusServing && c.hasTag(TagReadiness) && c.critical {
 				overall = StatusNotServing
 			}
 		}
+		if _, degraded := s.readiness(); degraded {
+			data["degraded"] = true
+		}
 		data["status"] = overall
 		data["services"] = services
-
-	} else {
+	default:
 		data["status"] = check(service)
 	}
+	if s.conf.Details {
+		if services, ok := data["services"].(map[string]Status); ok {
+			results := s.Results()
+			details := make(map[string]Result)
+			for service := range services {
+				details[service] = results[service]
+			}
+			data["details"] = details
+		}
+	}
+	code := http.StatusOK
+	if data["status"] != StatusServing {
+		code = http.StatusServiceUnavailable
+	}
 	b, err := json.Marshal(data)
 	if err != nil {
 		b = []byte(fmt.Sprintf(`{"status":%d}`, StatusNotServing))
+		code = http.StatusServiceUnavailable
 		s.log.Errorf("failed to marshal data, err: %v", err)
 	}
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	w.Write(b)
 }
 
+// liveness return status of the liveness probe, which is NOT_SERVING
+// if one of the liveness checkers failed in the latest check.
+func (s *MServer) liveness() (Status, map[string]Status) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	overall := StatusServing
+	services := make(map[string]Status)
+	for service, c := range s.checks {
+		if !c.hasTag(TagLiveness) {
+			continue
+		}
+		status := StatusServing
+		if rs, ok := s.results[service]; ok {
+			status = rs.Status
+		}
+		services[service] = status
+		if status != StatusServing {
+			overall = StatusNotServing
+		}
+	}
+	return overall, services
+}
+
+// startup return status of the startup probe, which is SERVING once
+// the initial checks completed and the application is marked as started.
+func (s *MServer) startup() Status {
+	if s.initialized.Load() && s.started.Load() {
+		return StatusServing
+	}
+	return StatusNotServing
+}
+
+// MarkStarted implements StartupMarker.
+func (s *MServer) MarkStarted() {
+	s.started.Store(true)
+}
+
 // SetStatus implements health.Server
+// If the service is a registered checker, its result is updated and the overall status
+// is updated immediately, so that components can push status changes without waiting
+// for the next check, i.e: on disconnected.
 func (s *MServer) SetStatus(service string, status Status) {
+	if service == OverallServiceName {
+		s.server.SetServingStatus(service, status)
+		return
+	}
+	s.mu.Lock()
+	if _, ok := s.checks[service]; !ok {
+		s.mu.Unlock()
+		s.server.SetServingStatus(service, status)
+		return
+	}
+	rs, ok := s.results[service]
+	if !ok {
+		rs = &Result{}
+		s.results[service] = rs
+	}
+	rs.Status = status
+	rs.LastCheck = time.Now()
+	if status == StatusServing {
+		rs.Error = ""
+		rs.LastSuccess = rs.LastCheck
+		rs.ConsecutiveFailures = 0
+	} else {
+		rs.Error = "status set to " + status.String()
+	}
+	s.mu.Unlock()
 	s.server.SetServingStatus(service, status)
+	s.updateOverall()
 }
 
 // Close implements health.Server.
+// It stops the scheduled checks and waits for the running checks to exit.
 func (s *MServer) Close() error {
-	if s.ticker != nil {
-		s.ticker.Stop()
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
 	}
-	if s.server != nil {
-		s.server.Shutdown()
+	s.closed = true
+	s.loopCancel = nil
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
 	}
+	s.wg.Wait()
+	s.server.Shutdown()
 	return nil
 }
+
+// checkers return a snapshot of the registered checkers.
+func (s *MServer) checkers() map[string]*checker {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	checks := make(map[string]*checker, len(s.checks))
+	for name, c := range s.checks {
+		checks[name] = c
+	}
+	return checks
+}
@@ -22,6 +22,23 @@ func Timeout(d time.Duration) ServerOption {
 	}
 }
 
+// FailureThreshold is an option to set number of consecutive failures
+// before a service is flipped to NOT_SERVING. Default is 1.
+func FailureThreshold(n int) ServerOption {
+	return func(srv *MServer) {
+		srv.conf.FailureThreshold = n
+	}
+}
+
+// Details is an option to include details of the check results: last error, last success time,
+// duration and consecutive failures in the HTTP responses. It should be enabled only
+// if the health check endpoint is not public as the errors might leak information.
+func Details(enable bool) ServerOption {
+	return func(srv *MServer) {
+		srv.conf.Details = enable
+	}
+}
+
 // Logger is an option to set logger for the health check server.
 func Logger(l log.Logger) ServerOption {
 	return func(srv *MServer) {
@@ -7,10 +7,14 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/pthethanh/micro/health"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -87,8 +91,12 @@ func TestHealthCheck(t *testing.T) {
 			t.Fatal(err)
 		}
 		srv.ServeHTTP(w, req)
-		if w.Result().StatusCode != http.StatusOK {
-			t.Fatalf("got status_code=%d, want status_code=%d", w.Code, http.StatusOK)
+		code := http.StatusOK
+		if expect != health.StatusServing {
+			code = http.StatusServiceUnavailable
+		}
+		if w.Result().StatusCode != code {
+			t.Fatalf("got status_code=%d, want status_code=%d", w.Code, code)
 		}
 		var m map[string]interface{}
 		if err := json.NewDecoder(w.Body).Decode(&m); err != nil {
@@ -119,3 +127,246 @@ func TestHealthCheck(t *testing.T) {
 	testGRPC("pkg.v1.MyService3", health.StatusServing)
 	testHTTP(health.StatusServing)
 }
+
+func TestProbes(t *testing.T) {
+	var liveDown, readyDown atomic.Bool
+	liveDown.Store(true)
+	readyDown.Store(true)
+	checkFunc := func(down *atomic.Bool) health.CheckFunc {
+		return func(ctx context.Context) error {
+			if down.Load() {
+				return errors.New("down")
+			}
+			return nil
+		}
+	}
+	srv := health.NewServer(map[string]health.Checker{
+		"db":    health.Liveness(checkFunc(&liveDown)),
+		"cache": checkFunc(&readyDown),
+	}, health.Interval(50*time.Millisecond))
+
+	test := func(probe string, want int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal/health/"+probe, nil))
+		if w.Code != want {
+			t.Errorf("got %s status_code=%d, want status_code=%d", probe, w.Code, want)
+		}
+	}
+	test(health.StartupProbe, http.StatusServiceUnavailable)
+	srv.Init(health.StatusServing)
+	defer srv.Close()
+	test(health.LivenessProbe, http.StatusServiceUnavailable)
+	test(health.ReadinessProbe, http.StatusServiceUnavailable)
+	// startup probe requires the application to be marked as started.
+	test(health.StartupProbe, http.StatusServiceUnavailable)
+	srv.MarkStarted()
+	test(health.StartupProbe, http.StatusOK)
+
+	// readiness only checker doesn't fail liveness.
+	liveDown.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	test(health.LivenessProbe, http.StatusOK)
+	test(health.ReadinessProbe, http.StatusServiceUnavailable)
+
+	readyDown.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	test(health.ReadinessProbe, http.StatusOK)
+}
+
+func TestResults(t *testing.T) {
+	var down atomic.Bool
+	srv := health.NewServer(map[string]health.Checker{
+		"db": health.CheckFunc(func(ctx context.Context) error {
+			if down.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		}),
+	}, health.Interval(50*time.Millisecond), health.FailureThreshold(3), health.Details(true))
+	srv.Init(health.StatusServing)
+	defer srv.Close()
+	if rs := srv.Results()["db"]; rs.Status != health.StatusServing || rs.LastSuccess.IsZero() {
+		t.Fatalf("got result=%+v, want SERVING with last success", rs)
+	}
+	down.Store(true)
+	// still serving until the failures reach the threshold.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rs := srv.Results()["db"]
+		if want := rs.ConsecutiveFailures < 3; (rs.Status == health.StatusServing) != want {
+			t.Fatalf("got result=%+v, want SERVING=%v", rs, want)
+		}
+		if rs.ConsecutiveFailures >= 3 {
+			if rs.Error != "connection refused" {
+				t.Fatalf("got error=%s, want error=connection refused", rs.Error)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for failures")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal/health", nil))
+	var body struct {
+		Details map[string]struct {
+			Error               string `json:"error"`
+			ConsecutiveFailures int    `json:"consecutive_failures"`
+		} `json:"details"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if d := body.Details["db"]; d.Error != "connection refused" || d.ConsecutiveFailures < 3 {
+		t.Errorf("got details=%+v, want details of the failures", d)
+	}
+	if n := testutil.CollectAndCount(srv, "health_check_status", "health_check_consecutive_failures"); n != 2 {
+		t.Errorf("got metrics=%d, want metrics=2", n)
+	}
+}
+
+func TestCheckerOptions(t *testing.T) {
+	var fastCalls, slowCalls atomic.Int32
+	srv := health.NewServer(map[string]health.Checker{
+		"fast": health.WithOptions(health.CheckFunc(func(ctx context.Context) error {
+			fastCalls.Add(1)
+			return nil
+		}), health.CheckerInterval(20*time.Millisecond)),
+		"slow": health.CheckFunc(func(ctx context.Context) error {
+			slowCalls.Add(1)
+			return nil
+		}),
+		"timeout": health.WithOptions(health.CheckFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), health.CheckerTimeout(10*time.Millisecond), health.NonCritical()),
+		"broker": health.CheckFunc(func(ctx context.Context) error {
+			return nil
+		}),
+	}, health.Interval(time.Hour))
+	srv.Init(health.StatusServing)
+	defer srv.Close()
+
+	time.Sleep(200 * time.Millisecond)
+	if n := fastCalls.Load(); n < 3 {
+		t.Errorf("got fast checks=%d, want checks >= 3", n)
+	}
+	if n := slowCalls.Load(); n != 1 {
+		t.Errorf("got slow checks=%d, want checks=1", n)
+	}
+	status := func(service string) health.Status {
+		rs, err := srv.Check(context.Background(), &health.CheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs.Status
+	}
+	// non-critical failure degrades but doesn't fail the overall status.
+	if got := status("timeout"); got != health.StatusNotServing {
+		t.Errorf("got timeout status=%v, want status=%v", got, health.StatusNotServing)
+	}
+	if got := status(health.OverallServiceName); got != health.StatusServing {
+		t.Errorf("got overall status=%v, want status=%v", got, health.StatusServing)
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal/health/ready", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"degraded":true`) {
+		t.Errorf("got status_code=%d, body=%s, want status_code=200 and degraded", w.Code, w.Body.String())
+	}
+
+	// pushed status is applied immediately.
+	srv.SetStatus("broker", health.StatusNotServing)
+	if got := status(health.OverallServiceName); got != health.StatusNotServing {
+		t.Errorf("got overall status=%v, want status=%v after pushed", got, health.StatusNotServing)
+	}
+	srv.SetStatus("broker", health.StatusServing)
+	if got := status(health.OverallServiceName); got != health.StatusServing {
+		t.Errorf("got overall status=%v, want status=%v after pushed", got, health.StatusServing)
+	}
+}
+
+func TestCloseStopsGoroutines(t *testing.T) {
+	waitGoroutines := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for runtime.NumGoroutine() > want {
+			if time.Now().After(deadline) {
+				t.Fatalf("got goroutines=%d, want goroutines<=%d", runtime.NumGoroutine(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	base := runtime.NumGoroutine()
+
+	// no checks, no goroutines.
+	srv := health.NewServer(map[string]health.Checker{})
+	srv.Init(health.StatusServing)
+	if n := runtime.NumGoroutine(); n > base {
+		t.Errorf("got goroutines=%d, want goroutines=%d when there is no checks", n, base)
+	}
+	srv.Close()
+
+	srv = health.NewServer(map[string]health.Checker{
+		"ok": health.CheckFunc(func(ctx context.Context) error {
+			return nil
+		}),
+		"slow": health.WithOptions(health.CheckFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), health.CheckerInterval(10*time.Millisecond)),
+	}, health.Interval(10*time.Millisecond), health.Timeout(time.Hour))
+	go srv.Init(health.StatusServing)
+	time.Sleep(50 * time.Millisecond)
+	srv.AddChecker("added", health.CheckFunc(func(ctx context.Context) error {
+		return nil
+	}))
+	if err := srv.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// close twice must be safe.
+	srv.Close()
+	waitGoroutines(base)
+}
+
+func TestAddRemoveChecker(t *testing.T) {
+	srv := health.NewServer(map[string]health.Checker{}, health.Interval(time.Hour))
+	srv.Init(health.StatusServing)
+	defer srv.Close()
+	status := func(service string) health.Status {
+		rs, err := srv.Check(context.Background(), &health.CheckRequest{Service: service})
+		if err != nil {
+			return health.StatusServiceUnknown
+		}
+		return rs.Status
+	}
+	wait := func(service string, want health.Status) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for status(service) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("got %s status=%v, want status=%v", service, status(service), want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	srv.AddChecker("db", health.CheckFunc(func(ctx context.Context) error {
+		return errors.New("down")
+	}))
+	wait("db", health.StatusNotServing)
+	wait(health.OverallServiceName, health.StatusNotServing)
+
+	srv.AddChecker("cache", health.CheckFunc(func(ctx context.Context) error {
+		return nil
+	}))
+	wait("cache", health.StatusServing)
+
+	srv.RemoveChecker("db")
+	wait("db", health.StatusServiceUnknown)
+	wait(health.OverallServiceName, health.StatusServing)
+	if _, ok := srv.Results()["db"]; ok {
+		t.Errorf("got result of removed checker, want no result")
+	}
+}
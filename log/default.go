@@ -29,15 +29,33 @@ func Root() Logger {
 }
 
 // Init init the root logger with options.
+// The implementation of the logger is selected by the backend option, default is Logrus.
 func Init(opts ...Option) error {
-	l := &Logrus{}
-	if err := l.Init(opts...); err != nil {
+	l, err := New(opts...)
+	if err != nil {
 		return err
 	}
 	root = l
 	return nil
 }
 
+// New return a new logger with options.
+// The implementation of the logger is selected by the backend option, default is Logrus.
+func New(opts ...Option) (Logger, error) {
+	options := &Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	backend, err := options.GetBackend()
+	if err != nil {
+		return nil, err
+	}
+	if backend == BackendSlog {
+		return NewSlog(opts...)
+	}
+	return NewLogrus(opts...)
+}
+
 // Infof print info with format.
 func Infof(format string, v ...interface{}) {
 	Root().Infof(format, v...)
@@ -94,6 +112,7 @@ func Fields(kv ...interface{}) Logger {
 }
 
 // Context return a logger from the given context.
+// The trace_id and span_id of the active span in the context are added to the logger, if any.
 func Context(ctx context.Context) Logger {
 	return Root().Context(ctx)
 }
@@ -1,6 +1,7 @@
 package log_test
 
 import (
+	"log/slog"
 	"os"
 	"time"
 
@@ -32,3 +33,8 @@ func ExampleInit_withOptions() {
 	)
 	log.Info("hello")
 }
+
+func ExampleNewSlogHandler() {
+	slog.SetDefault(slog.New(log.NewSlogHandler(log.Root())))
+	slog.Info("hello", "name", "micro")
+}
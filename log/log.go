@@ -50,6 +50,8 @@ type (
 		Fields(kv ...interface{}) Logger
 
 		// Context provide a way to get a context logger,  i.e... with request-id.
+		// Implementations should add TraceFields of the context to the logger,
+		// the package level Context and FromContext add them for any logger inside the context.
 		Context(ctx context.Context) Logger
 	}
 
@@ -63,6 +65,7 @@ type (
 		TimeFormat string            `envconfig:"LOG_TIME_FORMAT" default:"Mon, 02 Jan 2006 15:04:05 -0700"`
 		Output     string            `envconfig:"LOG_OUTPUT"`
 		Fields     map[string]string `envconfig:"LOG_FIELDS"`
+		Backend    Backend           `envconfig:"LOG_BACKEND" default:"logrus"`
 		writer     io.Writer
 	}
 	// Option is an option for configure logger.
@@ -73,6 +76,9 @@ type (
 
 	// Format is log format
 	Format string
+
+	// Backend is the implementation of the logger.
+	Backend string
 )
 
 const (
@@ -80,6 +86,10 @@ const (
 	filePrefix            = "file://"
 	// CorrelationID is field name of Correlation ID that is used to track related logs.
 	CorrelationID string = "correlation_id"
+	// TraceID is field name of the OpenTelemetry trace id of the active span.
+	TraceID string = "trace_id"
+	// SpanID is field name of the OpenTelemetry span id of the active span.
+	SpanID string = "span_id"
 )
 
 // These are the different logging levels.
@@ -110,6 +120,12 @@ const (
 	FormatText Format = "text"
 )
 
+// Backends of logger.
+const (
+	BackendLogrus Backend = "logrus"
+	BackendSlog   Backend = "slog"
+)
+
 // NewContext return a new logger context.
 func NewContext(ctx context.Context, logger Logger) context.Context {
 	if logger == nil {
@@ -119,14 +135,24 @@ func NewContext(ctx context.Context, logger Logger) context.Context {
 }
 
 // FromContext get logger form context.
+// The trace_id and span_id of the active span in the context are added to the logger, if any.
 func FromContext(ctx context.Context) Logger {
 	if ctx == nil {
 		return Root()
 	}
-	if logger, ok := ctx.Value(loggerKey).(Logger); ok {
-		return logger
+	logger, ok := ctx.Value(loggerKey).(Logger)
+	if !ok {
+		logger = Root()
+	}
+	return withTraceFields(ctx, logger)
+}
+
+// withTraceFields adds TraceFields of the context to the logger, if any.
+func withTraceFields(ctx context.Context, logger Logger) Logger {
+	if kv := TraceFields(ctx); len(kv) > 0 {
+		return logger.Fields(kv...)
 	}
-	return Root()
+	return logger
 }
 
 // GetWriter return writer output. If the given output is not valid, os.Stdout is returned.
@@ -148,6 +174,18 @@ func (opts Options) GetWriter() (io.Writer, error) {
 	}
 }
 
+// GetBackend return backend of the logger. If backend is empty, Logrus is returned.
+func (opts Options) GetBackend() (Backend, error) {
+	switch opts.Backend {
+	case "":
+		return BackendLogrus, nil
+	case BackendLogrus, BackendSlog:
+		return opts.Backend, nil
+	default:
+		return "", fmt.Errorf("log: backend not supported: %s", opts.Backend)
+	}
+}
+
 // GetLevel return log level. If the given level is not valid, LevelDebug is returned.
 func (opts Options) GetLevel() (Level, error) {
 	if opts.Level < LevelPanic || opts.Level > LevelTrace {
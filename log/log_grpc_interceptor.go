@@ -11,13 +11,13 @@ import (
 )
 
 // StreamInterceptor returns a grpc.StreamServerInterceptor that provides
-// a context logger with correlation_id. It will try to looks for value of X-Correlation-ID or X-Request-ID
+// a context logger with correlation_id, and trace_id, span_id if there is an active span. It will try to looks for value of X-Correlation-ID or X-Request-ID
 // in the metadata of the incoming request. If no value is provided, a new UUID will be generated.
 // For REST API via gRPC Gateway, pass the value of X-Correlation-ID or X-Request-ID in the header.
 func StreamInterceptor(l Logger) grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		correlationID, _ := contextutil.CorrelationIDFromContext(ss.Context())
-		logger := l.Fields(CorrelationID, correlationID, "method", info.FullMethod)
+		logger := l.Fields(CorrelationID, correlationID, "method", info.FullMethod).Fields(TraceFields(ss.Context())...)
 		newCtx := NewContext(ss.Context(), logger)
 		wrapped := grpc_middleware.WrapServerStream(ss)
 		wrapped.WrappedContext = newCtx
@@ -30,13 +30,13 @@ func StreamInterceptor(l Logger) grpc.StreamServerInterceptor {
 }
 
 // UnaryInterceptor returns a grpc.UnaryServerInterceptor that provides
-// a context logger with correlation_id. It will try to looks for value of X-Correlation-ID or X-Request-ID
+// a context logger with correlation_id, and trace_id, span_id if there is an active span. It will try to looks for value of X-Correlation-ID or X-Request-ID
 // in the metadata of the incoming request. If no value is provided, a new UUID will be generated.
 // For REST API via gRPC Gateway, pass the value of X-Correlation-ID or X-Request-ID in the header.
 func UnaryInterceptor(l Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		correlationID, _ := contextutil.CorrelationIDFromContext(ctx)
-		logger := l.Fields(CorrelationID, correlationID, "method", info.FullMethod)
+		logger := l.Fields(CorrelationID, correlationID, "method", info.FullMethod).Fields(TraceFields(ctx)...)
 		newCtx := NewContext(ctx, logger)
 		return runWithLog(logger, info.FullMethod, func() (interface{}, error) {
 			return handler(newCtx, req)
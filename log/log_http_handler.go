@@ -8,7 +8,8 @@ import (
 	"github.com/pthethanh/micro/util/contextutil"
 )
 
-// NewHTTPContextHandler provides a context logger with correlation_id and other basic HTTP information.
+// NewHTTPContextHandler provides a context logger with correlation_id and other basic HTTP information,
+// and trace_id, span_id if there is an active span.
 // Value of correlation_id will be retrieved from X-Correlation-ID or X-Request-ID in header of the incoming request.
 // If no value of correlation_id is provided, a new UUID will be generated.
 // This middleware should be used for HTTP handler only.
@@ -22,7 +23,7 @@ func NewHTTPContextHandler(l Logger) func(http.Handler) http.Handler {
 				CorrelationID, getCorrelationID(r),
 				"path", r.URL.Path,
 				"remote_addr", r.RemoteAddr,
-				"method", r.Method)
+				"method", r.Method).Fields(TraceFields(ctx)...)
 			r = r.WithContext(NewContext(ctx, logger))
 			mw := &responseWriter{
 				ResponseWriter: w,
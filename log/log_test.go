@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/pthethanh/micro/log"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestLog(t *testing.T) {
@@ -113,3 +114,62 @@ func TestLogWriter(t *testing.T) {
 	}
 	test(&bytes.Buffer{})
 }
+
+type fieldsLogger struct {
+	log.Logger
+	fields []interface{}
+}
+
+func (l *fieldsLogger) Fields(kv ...interface{}) log.Logger {
+	return &fieldsLogger{fields: append(append([]interface{}{}, l.fields...), kv...)}
+}
+
+func TestContextTraceFields(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	// Logrus
+	buf := &bytes.Buffer{}
+	l, err := log.NewLogrus(log.WithWriter(buf), log.WithLevel(log.LevelInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Context(ctx).Info("traced")
+	l.Context(context.Background()).Info("not traced")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got logs=%s, want 2 lines", buf.String())
+	}
+	for _, want := range []string{
+		fmt.Sprintf(`"%s":"%s"`, log.TraceID, traceID),
+		fmt.Sprintf(`"%s":"%s"`, log.SpanID, spanID),
+	} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("got log=%s, want log contains %s", lines[0], want)
+		}
+	}
+	if strings.Contains(lines[1], log.TraceID) {
+		t.Errorf("got log=%s, want no %s", lines[1], log.TraceID)
+	}
+
+	// any other logger inside the context.
+	ctx = log.NewContext(ctx, &fieldsLogger{})
+	for name, f := range map[string]func(context.Context) log.Logger{
+		"Context":     log.Context,
+		"FromContext": log.FromContext,
+	} {
+		got, ok := f(ctx).(*fieldsLogger)
+		if !ok {
+			t.Fatalf("%s: got logger=%T, want logger=%T", name, f(ctx), &fieldsLogger{})
+		}
+		kv := fmt.Sprintln(got.fields...)
+		if !strings.Contains(kv, log.TraceID+" "+traceID.String()) || !strings.Contains(kv, log.SpanID+" "+spanID.String()) {
+			t.Errorf("%s: got fields=%v, want fields contain %s and %s", name, got.fields, log.TraceID, log.SpanID)
+		}
+	}
+}
@@ -124,23 +124,27 @@ func (l *Logrus) Fields(kv ...interface{}) Logger {
 }
 
 // Context return new logger from context.
+// The trace_id and span_id of the active span in the context are added to the logger, if any.
 func (l *Logrus) Context(ctx context.Context) Logger {
 	if ctx == nil {
 		return l
 	}
-	// return if there's a logger inside the context
+	var logger Logger = l
+	// use the logger inside the context if there is,
 	// mostly through the interceptor.
-	if logger, ok := ctx.Value(loggerKey).(Logger); ok {
+	if cl, ok := ctx.Value(loggerKey).(Logger); ok {
 		kv := make([]interface{}, 0)
 		for k, v := range l.logger.Data {
 			kv = append(kv, k, v)
 		}
-		return logger.Fields(kv...)
+		logger = cl.Fields(kv...)
+	} else if correlationID, ok := contextutil.CorrelationIDFromContext(ctx); ok {
+		// if it's not a context logger, try to extract correlation_id from the context
+		// for tracing purpose.
+		logger = l.Fields(contextutil.XCorrelationID, correlationID)
 	}
-	// if it's not a context logger, try to extract correlation_id from the context
-	// for tracing purpose.
-	if correlationID, ok := contextutil.CorrelationIDFromContext(ctx); ok {
-		return l.Fields(contextutil.XCorrelationID, correlationID)
+	if kv := TraceFields(ctx); len(kv) > 0 {
+		logger = logger.Fields(kv...)
 	}
-	return l
+	return logger
 }
@@ -15,6 +15,7 @@ import (
 // LOG_TIME_FORMAT default:"Mon, 02 Jan 2006 15:04:05 -0700"
 // LOG_OUTPUT, default to be stdout, use file://my.log for writing to a file.
 // LOG_FIELDS is a map of key/value. i.e: name:myservice,site:vietnam
+// LOG_BACKEND default:"logrus", use slog for the log/slog based logger.
 func FromEnv(readOpts ...config.ReadOption) Option {
 	v := &Options{}
 	if err := envconfig.Read(v, readOpts...); err != nil {
@@ -30,6 +31,7 @@ func FromOptions(opts *Options) Option {
 		v.Format = opts.Format
 		v.Level = opts.Level
 		v.TimeFormat = opts.TimeFormat
+		v.Backend = opts.Backend
 	}
 }
 
@@ -47,6 +49,14 @@ func WithFormat(f Format) Option {
 	}
 }
 
+// WithBackend provides an option to set the implementation of the logger.
+// The option takes effect on Init and New only.
+func WithBackend(b Backend) Option {
+	return func(opts *Options) {
+		opts.Backend = b
+	}
+}
+
 // WithTimeFormat provides an option to set time format for logger.
 func WithTimeFormat(f string) Option {
 	return func(opts *Options) {
@@ -0,0 +1,235 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/pthethanh/micro/util/contextutil"
+)
+
+type (
+	// Slog implement Logger interface using log/slog.
+	Slog struct {
+		base   *slog.Logger
+		logger *slog.Logger
+		// fields are key value pairs with unique keys,
+		// so that a field can be overridden as in Logrus.
+		fields []interface{}
+	}
+)
+
+// Levels of slog which are not defined by log/slog.
+const (
+	slogLevelTrace = slog.LevelDebug - 4
+	slogLevelFatal = slog.LevelError + 4
+	slogLevelPanic = slog.LevelError + 8
+)
+
+// NewSlog return new logger using log/slog.
+func NewSlog(opts ...Option) (*Slog, error) {
+	l := &Slog{}
+	if err := l.Init(opts...); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Init init the logger.
+func (l *Slog) Init(opts ...Option) error {
+	options := &Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	format, err := options.GetFormat()
+	if err != nil {
+		return err
+	}
+	level, err := options.GetLevel()
+	if err != nil {
+		return err
+	}
+	out, err := options.GetWriter()
+	if err != nil {
+		return err
+	}
+	handlerOpts := &slog.HandlerOptions{
+		Level: slogLevel(level),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				if options.TimeFormat != "" {
+					return slog.String(a.Key, a.Value.Time().Format(options.TimeFormat))
+				}
+			case slog.LevelKey:
+				switch a.Value.Any().(slog.Level) {
+				case slogLevelTrace:
+					return slog.String(a.Key, "TRACE")
+				case slogLevelFatal:
+					return slog.String(a.Key, "FATAL")
+				case slogLevelPanic:
+					return slog.String(a.Key, "PANIC")
+				}
+			}
+			return a
+		},
+	}
+	var h slog.Handler
+	switch format {
+	case FormatText:
+		h = slog.NewTextHandler(out, handlerOpts)
+	default:
+		h = slog.NewJSONHandler(out, handlerOpts)
+	}
+	fields := make([]interface{}, 0, len(options.Fields)*2)
+	for _, k := range sortedKeys(options.Fields) {
+		fields = append(fields, k, options.Fields[k])
+	}
+	l.base = slog.New(h)
+	l.logger = l.base.With(fields...)
+	l.fields = fields
+	return nil
+}
+
+// Info print info
+func (l *Slog) Info(v ...interface{}) {
+	l.log(slog.LevelInfo, sprintln(v...))
+}
+
+// Debug print debug
+func (l *Slog) Debug(v ...interface{}) {
+	l.log(slog.LevelDebug, sprintln(v...))
+}
+
+// Warn print warning
+func (l *Slog) Warn(v ...interface{}) {
+	l.log(slog.LevelWarn, sprintln(v...))
+}
+
+// Error print error
+func (l *Slog) Error(v ...interface{}) {
+	l.log(slog.LevelError, sprintln(v...))
+}
+
+// Panic panic
+func (l *Slog) Panic(v ...interface{}) {
+	msg := sprintln(v...)
+	l.log(slogLevelPanic, msg)
+	panic(msg)
+}
+
+// Infof print info with format.
+func (l *Slog) Infof(format string, v ...interface{}) {
+	l.log(slog.LevelInfo, fmt.Sprintf(format, v...))
+}
+
+// Debugf print debug with format.
+func (l *Slog) Debugf(format string, v ...interface{}) {
+	l.log(slog.LevelDebug, fmt.Sprintf(format, v...))
+}
+
+// Warnf print warning with format.
+func (l *Slog) Warnf(format string, v ...interface{}) {
+	l.log(slog.LevelWarn, fmt.Sprintf(format, v...))
+}
+
+// Errorf print error with format.
+func (l *Slog) Errorf(format string, v ...interface{}) {
+	l.log(slog.LevelError, fmt.Sprintf(format, v...))
+}
+
+// Panicf panic with format.
+func (l *Slog) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.log(slogLevelPanic, msg)
+	panic(msg)
+}
+
+// Fields return a new logger with fields.
+func (l *Slog) Fields(kv ...interface{}) Logger {
+	m := fields(kv...)
+	merged := make([]interface{}, len(l.fields), len(l.fields)+len(m)*2)
+	copy(merged, l.fields)
+	for i := 0; i < len(merged); i += 2 {
+		if v, ok := m[merged[i].(string)]; ok {
+			merged[i+1] = v
+			delete(m, merged[i].(string))
+		}
+	}
+	for _, k := range sortedKeys(m) {
+		merged = append(merged, k, m[k])
+	}
+	return &Slog{
+		base:   l.base,
+		logger: l.base.With(merged...),
+		fields: merged,
+	}
+}
+
+// Context return new logger from context.
+// The trace_id and span_id of the active span in the context are added to the logger, if any.
+func (l *Slog) Context(ctx context.Context) Logger {
+	if ctx == nil {
+		return l
+	}
+	var logger Logger = l
+	// use the logger inside the context if there is,
+	// mostly through the interceptor.
+	if cl, ok := ctx.Value(loggerKey).(Logger); ok {
+		logger = cl.Fields(l.fields...)
+	} else if correlationID, ok := contextutil.CorrelationIDFromContext(ctx); ok {
+		// if it's not a context logger, try to extract correlation_id from the context
+		// for tracing purpose.
+		logger = l.Fields(contextutil.XCorrelationID, correlationID)
+	}
+	if kv := TraceFields(ctx); len(kv) > 0 {
+		logger = logger.Fields(kv...)
+	}
+	return logger
+}
+
+// Logger return the underlying slog.Logger.
+func (l *Slog) Logger() *slog.Logger {
+	return l.logger
+}
+
+func (l *Slog) log(level slog.Level, msg string) {
+	l.logger.Log(context.Background(), level, msg)
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelPanic:
+		return slogLevelPanic
+	case LevelFatal:
+		return slogLevelFatal
+	case LevelError:
+		return slog.LevelError
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelDebug:
+		return slog.LevelDebug
+	default:
+		return slogLevelTrace
+	}
+}
+
+func sprintln(v ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
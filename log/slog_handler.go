@@ -0,0 +1,112 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+type (
+	slogHandler struct {
+		logger Logger
+		group  string
+		fields []interface{}
+	}
+)
+
+// NewSlogHandler return a slog.Handler that writes the records to the given logger,
+// so that libraries using log/slog log through the configured logger, i.e:
+//
+//	slog.SetDefault(slog.New(log.NewSlogHandler(log.Root())))
+//
+// The level of a record is mapped to the closest level of the logger and the attributes
+// of the record are added as fields, the keys of the attributes in groups are qualified
+// with the group names, separated by dots. The logger is combined with the context
+// of the record via Logger.Context.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{
+		logger: l,
+	}
+}
+
+// Enabled implements slog.Handler. Records are always handled,
+// the level is filtered by the underlying logger.
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	kv := make([]interface{}, 0, len(h.fields)+r.NumAttrs()*2)
+	kv = append(kv, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		kv = appendAttr(kv, h.group, a)
+		return true
+	})
+	logger := h.logger
+	if ctx != nil {
+		logger = logger.Context(ctx)
+	}
+	if len(kv) > 0 {
+		logger = logger.Fields(kv...)
+	}
+	switch {
+	case r.Level >= slog.LevelError:
+		logger.Error(r.Message)
+	case r.Level >= slog.LevelWarn:
+		logger.Warn(r.Message)
+	case r.Level >= slog.LevelInfo:
+		logger.Info(r.Message)
+	default:
+		logger.Debug(r.Message)
+	}
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append(make([]interface{}, 0, len(h.fields)+len(attrs)*2), h.fields...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	return &slogHandler{
+		logger: h.logger,
+		group:  h.group,
+		fields: fields,
+	}
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{
+		logger: h.logger,
+		group:  qualify(h.group, name),
+		fields: h.fields,
+	}
+}
+
+func appendAttr(kv []interface{}, group string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kv
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group = qualify(group, a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			kv = appendAttr(kv, group, ga)
+		}
+		return kv
+	}
+	return append(kv, qualify(group, a.Key), a.Value.Any())
+}
+
+func qualify(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
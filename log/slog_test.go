@@ -0,0 +1,126 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/pthethanh/micro/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	lines := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		m := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid log line: %s, err: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestSlog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := log.NewSlog(log.WithWriter(buf), log.WithLevel(log.LevelInfo), log.WithFields("service", "micro"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("debug")
+	l.Info("info", 1)
+	l.Fields("k", "v1").Fields("k", "v2", "n", 1).Warnf("warn %d", 2)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = log.NewContext(ctx, l.Fields(log.CorrelationID, "123").Fields(log.TraceFields(ctx)...))
+	l.Context(ctx).Error("error")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 3 {
+		t.Fatalf("got logs=%s, want 3 lines", buf.String())
+	}
+	cases := []map[string]interface{}{
+		{"level": "INFO", "msg": "info 1", "service": "micro"},
+		{"level": "WARN", "msg": "warn 2", "service": "micro", "k": "v2", "n": float64(1)},
+		{"level": "ERROR", "msg": "error", "service": "micro", log.CorrelationID: "123", log.TraceID: traceID.String(), log.SpanID: spanID.String()},
+	}
+	for i, want := range cases {
+		for k, v := range want {
+			if lines[i][k] != v {
+				t.Errorf("got line %d %s=%v, want %s=%v", i, k, lines[i][k], k, v)
+			}
+		}
+	}
+	// fields must not be duplicated.
+	if n := strings.Count(buf.String(), `"`+log.TraceID+`"`); n != 1 {
+		t.Errorf("got %d %s fields, want 1", n, log.TraceID)
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	l, err := log.New(log.WithBackend(log.BackendSlog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.(*log.Slog); !ok {
+		t.Errorf("got logger=%T, want logger=%T", l, &log.Slog{})
+	}
+	if l, _ := log.New(); l == nil {
+		t.Error("got nil logger, want default logger")
+	} else if _, ok := l.(*log.Logrus); !ok {
+		t.Errorf("got logger=%T, want logger=%T", l, &log.Logrus{})
+	}
+	if _, err := log.New(log.WithBackend("unknown")); err == nil {
+		t.Error("got err=nil, want backend not supported error")
+	}
+
+	// cleanups run in reverse order, the root logger is restored after the env.
+	t.Cleanup(func() { log.Init(log.FromEnv()) })
+	t.Setenv("LOG_BACKEND", "slog")
+	if err := log.Init(log.FromEnv()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := log.Root().(*log.Slog); !ok {
+		t.Errorf("got root=%T, want root=%T", log.Root(), &log.Slog{})
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l, err := log.NewLogrus(log.WithWriter(buf), log.WithLevel(log.LevelInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(log.NewSlogHandler(l))
+	logger.Debug("debug")
+	logger.With("a", 1).WithGroup("g").Info("info", "k", "v", slog.Group("s", "x", true))
+	logger.Error("error", "err", "failed")
+
+	lines := decodeLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("got logs=%s, want 2 lines", buf.String())
+	}
+	cases := []map[string]interface{}{
+		{"level": "info", "msg": "info", "a": float64(1), "g.k": "v", "g.s.x": true},
+		{"level": "error", "msg": "error", "err": "failed"},
+	}
+	for i, want := range cases {
+		for k, v := range want {
+			if lines[i][k] != v {
+				t.Errorf("got line %d %s=%v, want %s=%v", i, k, lines[i][k], k, v)
+			}
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package log
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // MustJSON return JSON string of the given value.
@@ -14,3 +17,15 @@ func MustJSON(v interface{}) string {
 	}
 	return string(b)
 }
+
+// TraceFields return trace_id and span_id of the active span in the given context
+// as key value pairs, or nil if there is no active span. Logger implementations
+// should add them in Context, i.e: l.Fields(TraceFields(ctx)...), so that logs
+// can be joined with traces.
+func TraceFields(ctx context.Context) []interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []interface{}{TraceID, sc.TraceID().String(), SpanID, sc.SpanID().String()}
+}
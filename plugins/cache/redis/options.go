@@ -44,6 +44,12 @@ type (
 		// The sentinel master name.
 		// Only failover clients.
 		MasterName string `envconfig:"REDIS_MASTER_NAME"`
+
+		// Namespace is prefix of all keys managed by the cache, i.e: myservice:
+		Namespace string `envconfig:"REDIS_NAMESPACE"`
+		// FlushOnClose delete all keys of the namespace on close.
+		// It has no effect if namespace is empty. Mostly used for test setup.
+		FlushOnClose bool `envconfig:"REDIS_FLUSH_ON_CLOSE" default:"false"`
 	}
 
 	// Option is Redis configuration option.
@@ -79,6 +85,8 @@ func FromConfig(conf Config) Option {
 		r.opts.RouteRandomly = conf.RouteRandomly
 		r.opts.Username = conf.Username
 		r.opts.WriteTimeout = conf.WriteTimeout
+		r.namespace = conf.Namespace
+		r.flushOnClose = conf.FlushOnClose
 	}
 }
 
@@ -95,3 +103,21 @@ func FromUniversalOptions(opts *redis.UniversalOptions) Option {
 		r.opts = opts
 	}
 }
+
+// Namespace is an option to set prefix for all keys managed by the cache.
+// Services sharing the same Redis should use different namespaces, i.e: myservice:
+func Namespace(ns string) Option {
+	return func(r *Redis) {
+		r.namespace = ns
+	}
+}
+
+// FlushOnClose is an option to delete all keys of the namespace when the cache is closed.
+// For safety, keys are deleted only if a namespace is configured, see Namespace,
+// other keys in the same database are never touched.
+// This option is mostly used for test setup.
+func FlushOnClose(flush bool) Option {
+	return func(r *Redis) {
+		r.flushOnClose = flush
+	}
+}
@@ -2,6 +2,8 @@ package redis
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/pthethanh/micro/cache"
@@ -11,14 +13,54 @@ import (
 type (
 	// Redis is an implementation of cache.Cacher using Redis.
 	Redis struct {
-		opts *redis.UniversalOptions
-		conn redis.UniversalClient
+		opts         *redis.UniversalOptions
+		conn         redis.UniversalClient
+		namespace    string
+		flushOnClose bool
 	}
 )
 
 var (
-	_ cache.Cacher   = (*Redis)(nil)
-	_ health.Checker = (*Redis)(nil)
+	_ cache.Cacher    = (*Redis)(nil)
+	_ cache.TTLGetter = (*Redis)(nil)
+	_ health.Checker  = (*Redis)(nil)
+
+	// getScript get value of KEYS[1] and extend its expiration
+	// if sliding expiration info is stored in KEYS[2].
+	getScript = redis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if v then
+	local ttl = redis.call("GET", KEYS[2])
+	if ttl then
+		redis.call("PEXPIRE", KEYS[1], ttl)
+		redis.call("PEXPIRE", KEYS[2], ttl)
+	end
+end
+return v
+`)
+
+	// setKeepTTLScript set value ARGV[1] to KEYS[1] and retain its TTL if the key exists,
+	// otherwise set the value with TTL ARGV[2] and sliding expiration info ARGV[3] stored in KEYS[2].
+	setKeepTTLScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "XX", "KEEPTTL") then
+	return "OK"
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+else
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[2], ARGV[3], "PX", ARGV[2])
+else
+	redis.call("DEL", KEYS[2])
+end
+return "OK"
+`)
+)
+
+const (
+	slidingInfix = ":sliding:"
 )
 
 // New return a new cacher using Redis.
@@ -42,45 +84,158 @@ func (r *Redis) Open(ctx context.Context) error {
 }
 
 // Get a value, return cache.ErrNotFound if key not found.
+// Expiration of the key is extended if it was set with sliding expiration.
 func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
-	cmd := r.conn.Get(ctx, key)
+	k := r.key(key)
+	cmd := getScript.Run(ctx, r.conn, []string{k, r.slidingKey(k)})
 	if cmd.Err() == redis.Nil {
 		return nil, cache.ErrNotFound
 	}
 	if cmd.Err() != nil {
 		return nil, cmd.Err()
 	}
-	return cmd.Bytes()
+	v, err := cmd.Text()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(v), nil
 }
 
 // Set a value
 func (r *Redis) Set(ctx context.Context, key string, val []byte, opts ...cache.SetOption) error {
 	opt := &cache.SetOptions{}
 	opt.Apply(opts...)
-	if cmd := r.conn.Set(ctx, key, val, opt.TTL); cmd.Err() != nil {
-		return cmd.Err()
+	k := r.key(key)
+	sk := r.slidingKey(k)
+	ttl := opt.Expiration()
+	sliding := int64(0)
+	if opt.Sliding && ttl > 0 {
+		sliding = opt.TTL.Milliseconds()
 	}
-	return nil
+	if opt.KeepTTL {
+		return setKeepTTLScript.Run(ctx, r.conn, []string{k, sk}, val, ttl.Milliseconds(), sliding).Err()
+	}
+	_, err := r.conn.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, k, val, ttl)
+		if sliding > 0 {
+			pipe.Set(ctx, sk, sliding, ttl)
+		} else {
+			pipe.Del(ctx, sk)
+		}
+		return nil
+	})
+	return err
 }
 
 // Delete a value
 func (r *Redis) Delete(ctx context.Context, key string) error {
-	if cmd := r.conn.Del(ctx, key); cmd.Err() != nil && cmd.Err() != redis.Nil {
+	k := r.key(key)
+	if cmd := r.conn.Del(ctx, k, r.slidingKey(k)); cmd.Err() != nil && cmd.Err() != redis.Nil {
 		return cmd.Err()
 	}
 	return nil
 }
 
+// TTL return remaining time to live of the key.
+func (r *Redis) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.conn.PTTL(ctx, r.key(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch ttl {
+	case -2:
+		return 0, cache.ErrNotFound
+	case -1:
+		return cache.NoExpiration, nil
+	}
+	return ttl, nil
+}
+
 // CheckHealth return health check function for checking health.
 func (r *Redis) CheckHealth(ctx context.Context) error {
 	return r.conn.Ping(ctx).Err()
 }
 
-// Close flush and close the underlying connection.
+// Close close the underlying connection.
+// If FlushOnClose is enabled, all keys in the configured namespace
+// are deleted before the connection is closed.
 func (r *Redis) Close(ctx context.Context) error {
-	rs := r.conn.FlushAll(ctx)
-	if err := rs.Err(); err != nil {
-		return err
+	if r.flushOnClose && r.namespace != "" {
+		if err := r.flush(ctx); err != nil {
+			r.conn.Close()
+			return err
+		}
 	}
 	return r.conn.Close()
 }
+
+// flush delete all keys belong to the namespace.
+func (r *Redis) flush(ctx context.Context) error {
+	match := escapePattern(r.namespace) + "*"
+	if c, ok := r.conn.(*redis.ClusterClient); ok {
+		return c.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return deleteMatch(ctx, client, match)
+		})
+	}
+	return deleteMatch(ctx, r.conn, match)
+}
+
+func (r *Redis) key(k string) string {
+	return r.namespace + k
+}
+
+// slidingKey return key of sliding expiration info of the given namespaced key.
+// The key shares the same namespace and the same hash slot with the given key
+// so that both can be updated atomically in cluster mode, and it is unique per key.
+// Keys of the form {tag}:sliding:key are reserved.
+func (r *Redis) slidingKey(k string) string {
+	return r.namespace + "{" + hashTag(k) + "}" + slidingInfix + strings.TrimPrefix(k, r.namespace)
+}
+
+// deleteMatch scan and delete all keys matched the given pattern.
+func deleteMatch(ctx context.Context, c redis.UniversalClient, match string) error {
+	iter := c.Scan(ctx, 0, match, 100).Iterator()
+	pipe := c.Pipeline()
+	for iter.Next(ctx) {
+		// delete keys one by one to avoid CROSSSLOT error in cluster mode.
+		pipe.Del(ctx, iter.Val())
+		if pipe.Len() >= 100 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if pipe.Len() > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashTag return the part of the key used for calculating hash slot in cluster mode.
+// See https://redis.io/docs/reference/cluster-spec/#hash-tags
+func hashTag(k string) string {
+	if s := strings.IndexByte(k, '{'); s >= 0 {
+		if e := strings.IndexByte(k[s+1:], '}'); e > 0 {
+			return k[s+1 : s+1+e]
+		}
+	}
+	return k
+}
+
+// escapePattern escape special characters of glob-style pattern used by SCAN.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
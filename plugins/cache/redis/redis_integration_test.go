@@ -102,3 +102,141 @@ func TestCacheDelete(t *testing.T) {
 		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
 	}
 }
+
+func TestCacheFlushOnClose(t *testing.T) {
+	other := redis.New(redis.FromEnv())
+	if err := other.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close(context.Background())
+	if err := other.Set(context.Background(), "k4", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+
+	m := redis.New(redis.FromEnv(), redis.Namespace("test:"), redis.FlushOnClose(true))
+	if err := m.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(context.Background(), "k4", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// keys in the namespace must be deleted.
+	m = redis.New(redis.FromEnv(), redis.Namespace("test:"))
+	if err := m.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(context.Background())
+	if _, err := m.Get(context.Background(), "k4"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+	// keys outside of the namespace must not be touched.
+	if v, err := other.Get(context.Background(), "k4"); err != nil || string(v) != "v" {
+		t.Fatalf("got result=%v, err=%v, want result=%v, err=%v", string(v), err, "v", nil)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	m := redis.New(redis.FromEnv(), redis.Namespace("test_ttl:"), redis.FlushOnClose(true))
+	ctx := context.Background()
+	if err := m.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+
+	if _, err := m.TTL(ctx, "k"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+	if err := m.Set(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := m.TTL(ctx, "k"); err != nil || ttl != cache.NoExpiration {
+		t.Fatalf("got ttl=%v, err=%v, want ttl=%v, err=nil", ttl, err, cache.NoExpiration)
+	}
+	if err := m.Set(ctx, "k", []byte("v"), cache.TTL(time.Second), cache.Jitter(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := m.TTL(ctx, "k"); err != nil || ttl <= 0 || ttl > 2*time.Second {
+		t.Fatalf("got ttl=%v, err=%v, want ttl in range (0, 2s]", ttl, err)
+	}
+}
+
+func TestCacheKeepTTL(t *testing.T) {
+	m := redis.New(redis.FromEnv(), redis.Namespace("test_keep_ttl:"), redis.FlushOnClose(true))
+	ctx := context.Background()
+	if err := m.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+
+	if err := m.Set(ctx, "k", []byte("v1"), cache.TTL(300*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := m.Set(ctx, "k", []byte("v2"), cache.KeepTTL()); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := m.Get(ctx, "k"); err != nil || string(v) != "v2" {
+		t.Fatalf("got result=%v, err=%v, want result=%v, err=nil", string(v), err, "v2")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if _, err := m.Get(ctx, "k"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+}
+
+func TestCacheSliding(t *testing.T) {
+	m := redis.New(redis.FromEnv(), redis.Namespace("test_sliding:"), redis.FlushOnClose(true))
+	ctx := context.Background()
+	if err := m.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+
+	if err := m.Set(ctx, "k", []byte("v"), cache.TTL(300*time.Millisecond), cache.Sliding()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		time.Sleep(150 * time.Millisecond)
+		if _, err := m.Get(ctx, "k"); err != nil {
+			t.Fatalf("got err=%v, want err=nil", err)
+		}
+	}
+	time.Sleep(350 * time.Millisecond)
+	if _, err := m.Get(ctx, "k"); err != cache.ErrNotFound {
+		t.Fatalf("got err=%v, want err=%v", err, cache.ErrNotFound)
+	}
+}
+
+func TestCacheSlidingSameHashTag(t *testing.T) {
+	m := redis.New(redis.FromEnv(), redis.Namespace("test_sliding_tag:"), redis.FlushOnClose(true))
+	ctx := context.Background()
+	if err := m.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+
+	// keys sharing the same hash tag must have their own sliding expiration.
+	if err := m.Set(ctx, "{u1}:a", []byte("v"), cache.TTL(300*time.Millisecond), cache.Sliding()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(ctx, "{u1}:b", []byte("v"), cache.TTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		time.Sleep(150 * time.Millisecond)
+		if _, err := m.Get(ctx, "{u1}:a"); err != nil {
+			t.Fatalf("got err=%v, want err=nil", err)
+		}
+	}
+	ttl, err := m.TTL(ctx, "{u1}:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl < 30*time.Second {
+		t.Errorf("got ttl=%v, want ttl not changed by other keys", ttl)
+	}
+}
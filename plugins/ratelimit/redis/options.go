@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pthethanh/micro/config"
+	"github.com/pthethanh/micro/config/envconfig"
+)
+
+type (
+	// Config holds Redis rate limit store configuration.
+	Config struct {
+		Addrs []string `envconfig:"RATELIMIT_REDIS_ADDRS"`
+
+		// Database to be selected after connecting to the server.
+		// Only single-node and failover clients.
+		DB int `envconfig:"RATELIMIT_REDIS_DB"`
+
+		Username string `envconfig:"RATELIMIT_REDIS_USERNAME"`
+		Password string `envconfig:"RATELIMIT_REDIS_PASSWORD"`
+
+		MaxRetries      int           `envconfig:"RATELIMIT_REDIS_MAX_RETRIES" default:"3"`
+		MinRetryBackoff time.Duration `envconfig:"RATELIMIT_REDIS_MIN_RETRY_BACKOFF" default:"8ms"`
+		MaxRetryBackoff time.Duration `envconfig:"RATELIMIT_REDIS_MAX_RETRY_BACKOFF" default:"512ms"`
+
+		DialTimeout  time.Duration `envconfig:"RATELIMIT_REDIS_DIAL_TIMEOUT" default:"5s"`
+		ReadTimeout  time.Duration `envconfig:"RATELIMIT_REDIS_READ_TIMEOUT" default:"1s"`
+		WriteTimeout time.Duration `envconfig:"RATELIMIT_REDIS_WRITE_TIMEOUT" default:"1s"`
+
+		PoolSize     int           `envconfig:"RATELIMIT_REDIS_POOL_SIZE"`
+		MinIdleConns int           `envconfig:"RATELIMIT_REDIS_MIN_IDLE_CONNS"`
+		PoolTimeout  time.Duration `envconfig:"RATELIMIT_REDIS_POOL_TIMEOUT"`
+		IdleTimeout  time.Duration `envconfig:"RATELIMIT_REDIS_IDLE_TIMEOUT"`
+
+		// Only cluster clients.
+		MaxRedirects   int  `envconfig:"RATELIMIT_REDIS_MAX_REDIRECTS"`
+		RouteByLatency bool `envconfig:"RATELIMIT_REDIS_ROUTE_BY_LATENCY"`
+		RouteRandomly  bool `envconfig:"RATELIMIT_REDIS_ROUTE_RANDOMLY"`
+
+		// The sentinel master name.
+		// Only failover clients.
+		MasterName string `envconfig:"RATELIMIT_REDIS_MASTER_NAME"`
+
+		// Namespace is prefix of all keys managed by the store.
+		Namespace string `envconfig:"RATELIMIT_REDIS_NAMESPACE" default:"ratelimit:"`
+	}
+
+	// Option is Redis configuration option.
+	Option func(*Redis)
+)
+
+// ReadConfigFromEnv read Redis configuration from environment variables.
+func ReadConfigFromEnv(opts ...config.ReadOption) Config {
+	conf := Config{}
+	envconfig.Read(&conf, opts...)
+	return conf
+}
+
+// FromConfig is an option to configure the Redis store from a custom config.
+func FromConfig(conf Config) Option {
+	return func(r *Redis) {
+		r.opts.Addrs = conf.Addrs
+		r.opts.DB = conf.DB
+		r.opts.Username = conf.Username
+		r.opts.Password = conf.Password
+		r.opts.MaxRetries = conf.MaxRetries
+		r.opts.MinRetryBackoff = conf.MinRetryBackoff
+		r.opts.MaxRetryBackoff = conf.MaxRetryBackoff
+		r.opts.DialTimeout = conf.DialTimeout
+		r.opts.ReadTimeout = conf.ReadTimeout
+		r.opts.WriteTimeout = conf.WriteTimeout
+		r.opts.PoolSize = conf.PoolSize
+		r.opts.MinIdleConns = conf.MinIdleConns
+		r.opts.PoolTimeout = conf.PoolTimeout
+		r.opts.IdleTimeout = conf.IdleTimeout
+		r.opts.MaxRedirects = conf.MaxRedirects
+		r.opts.RouteByLatency = conf.RouteByLatency
+		r.opts.RouteRandomly = conf.RouteRandomly
+		r.opts.MasterName = conf.MasterName
+		r.namespace = conf.Namespace
+	}
+}
+
+// FromEnv is an option to configure the Redis store from environment variables.
+func FromEnv(opts ...config.ReadOption) Option {
+	conf := Config{}
+	envconfig.Read(&conf, opts...)
+	return FromConfig(conf)
+}
+
+// FromUniversalOptions is an option to configure the Redis store from the given options.
+func FromUniversalOptions(opts *redis.UniversalOptions) Option {
+	return func(r *Redis) {
+		r.opts = opts
+	}
+}
+
+// Namespace is an option to set prefix for all keys managed by the store.
+// Default namespace is ratelimit:
+func Namespace(ns string) Option {
+	return func(r *Redis) {
+		r.namespace = ns
+	}
+}
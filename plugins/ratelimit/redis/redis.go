@@ -0,0 +1,155 @@
+// Package redis provides an implementation of ratelimit.Store using Redis
+// which can be shared between multiple instances for cluster-wide limits.
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pthethanh/micro/health"
+	"github.com/pthethanh/micro/ratelimit"
+)
+
+type (
+	// Redis is an implementation of ratelimit.Store using Redis.
+	Redis struct {
+		opts      *redis.UniversalOptions
+		conn      redis.UniversalClient
+		namespace string
+	}
+)
+
+var (
+	_ ratelimit.Store = (*Redis)(nil)
+	_ health.Checker  = (*Redis)(nil)
+
+	// tokenBucketScript takes a token from the bucket KEYS[1] which holds
+	// maximum ARGV[2] tokens and is refilled at the rate ARGV[1] tokens per second.
+	// Time of the Redis server is used so that all instances share the same clock.
+	tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local v = redis.call("HMGET", KEYS[1], "t", "ts")
+local tokens = tonumber(v[1])
+local ts = tonumber(v[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call("HSET", KEYS[1], "t", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) * 1000 / rate) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+	// slidingWindowScript counts the request in the sliding window KEYS[1] of ARGV[2] milliseconds
+	// using the counts of the current and previous fixed windows, the request is allowed
+	// if the count doesn't exceed ARGV[1].
+	slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local size = tonumber(ARGV[2])
+local t = redis.call("TIME")
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local start = now - now % size
+local v = redis.call("HMGET", KEYS[1], "s", "p", "c")
+local s = tonumber(v[1])
+local p = tonumber(v[2]) or 0
+local c = tonumber(v[3]) or 0
+if s ~= start then
+	if s ~= nil and start - s == size then
+		p = c
+	else
+		p = 0
+	end
+	c = 0
+end
+local elapsed = now - start
+local count = p * (1 - elapsed / size) + c
+local allowed = 0
+local remaining = 0
+local retry = 0
+if count + 1 > limit then
+	local free = limit - 1 - c
+	if free >= 0 then
+		retry = size * (1 - free / p) - elapsed
+	else
+		retry = size - elapsed + size * (1 - (limit - 1) / c)
+	end
+else
+	c = c + 1
+	allowed = 1
+	remaining = math.floor(limit - count - 1)
+end
+redis.call("HSET", KEYS[1], "s", start, "p", p, "c", c)
+redis.call("PEXPIRE", KEYS[1], 2 * size)
+return {allowed, remaining, math.ceil(retry)}
+`)
+)
+
+// New return a new rate limit store using Redis.
+func New(opts ...Option) *Redis {
+	r := &Redis{
+		opts:      &redis.UniversalOptions{},
+		namespace: "ratelimit:",
+	}
+	for _, op := range opts {
+		op(r)
+	}
+	return r
+}
+
+// Open open connection to the target servers.
+func (r *Redis) Open(ctx context.Context) error {
+	r.conn = redis.NewUniversalClient(r.opts)
+	if err := r.conn.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TokenBucket implements ratelimit.Store.
+func (r *Redis) TokenBucket(ctx context.Context, key string, rate float64, burst int) (ratelimit.Result, error) {
+	return r.run(ctx, tokenBucketScript, key, rate, burst)
+}
+
+// SlidingWindow implements ratelimit.Store.
+// It approximates the count in the sliding window using the counts
+// of the current and previous fixed windows.
+func (r *Redis) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (ratelimit.Result, error) {
+	return r.run(ctx, slidingWindowScript, key, limit, window.Milliseconds())
+}
+
+// CheckHealth return health check function for checking health.
+func (r *Redis) CheckHealth(ctx context.Context) error {
+	return r.conn.Ping(ctx).Err()
+}
+
+// Close close the underlying connection.
+func (r *Redis) Close(ctx context.Context) error {
+	return r.conn.Close()
+}
+
+func (r *Redis) run(ctx context.Context, script *redis.Script, key string, args ...interface{}) (ratelimit.Result, error) {
+	v, err := script.Run(ctx, r.conn, []string{r.namespace + key}, args...).Int64Slice()
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return ratelimit.Result{
+		Allowed:    v[0] == 1,
+		Remaining:  int(v[1]),
+		RetryAfter: time.Duration(v[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,43 @@
+//go:build integration_test
+// +build integration_test
+
+package redis_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/plugins/ratelimit/redis"
+	"github.com/pthethanh/micro/ratelimit"
+)
+
+func TestStore(t *testing.T) {
+	os.Setenv("RATELIMIT_REDIS_ADDRS", "localhost:6379")
+	s := redis.New(redis.FromEnv(), redis.Namespace("ratelimit_test:"+time.Now().String()+":"))
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close(context.Background())
+
+	limiters := map[string]ratelimit.Limiter{
+		"token bucket":   ratelimit.TokenBucket(s, 3, time.Second, 3),
+		"sliding window": ratelimit.SlidingWindow(s, 3, time.Second),
+	}
+	for name, l := range limiters {
+		for i := 0; i < 3; i++ {
+			rs, err := l.Allow(context.Background(), "k")
+			if err != nil || !rs.Allowed || rs.Remaining != 2-i {
+				t.Fatalf("%s: got allowed=%v, remaining=%d, err=%v, want allowed=true, remaining=%d", name, rs.Allowed, rs.Remaining, err, 2-i)
+			}
+		}
+		rs, err := l.Allow(context.Background(), "k")
+		if err != nil || rs.Allowed || rs.RetryAfter <= 0 {
+			t.Fatalf("%s: got allowed=%v, retry after=%v, err=%v, want rejected", name, rs.Allowed, rs.RetryAfter, err)
+		}
+		if rs, _ := l.Allow(context.Background(), "other"); !rs.Allowed {
+			t.Fatalf("%s: got other key rejected, want allowed", name)
+		}
+	}
+}
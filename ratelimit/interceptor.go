@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/status"
+)
+
+const (
+	// RetryAfterMD is metadata name of the duration in seconds
+	// the client should wait before retrying a rate limited request.
+	RetryAfterMD = "retry-after"
+)
+
+// UnaryInterceptor returns a grpc.UnaryServerInterceptor that limits rate of requests
+// per client identified by the given KeyFunc. Rate limited requests are rejected
+// with codes.ResourceExhausted and retry-after header.
+// If the limiter fails, the requests are allowed.
+func UnaryInterceptor(l Limiter, key KeyFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if rs, ok := allow(ctx, l, key); !ok {
+			grpc.SetHeader(ctx, metadata.Pairs(RetryAfterMD, retryAfter(rs)))
+			return nil, exhausted(rs)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor returns a grpc.StreamServerInterceptor that limits rate of streams
+// per client identified by the given KeyFunc. Rate limited streams are rejected
+// with codes.ResourceExhausted and retry-after header.
+// If the limiter fails, the streams are allowed.
+func StreamInterceptor(l Limiter, key KeyFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if rs, ok := allow(ss.Context(), l, key); !ok {
+			ss.SetHeader(metadata.Pairs(RetryAfterMD, retryAfter(rs)))
+			return exhausted(rs)
+		}
+		return handler(srv, grpc_middleware.WrapServerStream(ss))
+	}
+}
+
+// HTTPInterceptor return a HTTP interceptor that limits rate of requests
+// per client identified by the given KeyFunc. The request headers are
+// available to the KeyFunc as incoming metadata and the remote address as peer.
+// Rate limited requests are rejected with status 429 and Retry-After header.
+func HTTPInterceptor(l Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rs, ok := allow(httpContext(r), l, key); !ok {
+				w.Header().Set("Retry-After", retryAfter(rs))
+				http.Error(w, fmt.Sprintf(`{"code":%d,"message":"%s"}`, codes.ResourceExhausted, "rate limit exceeded"), http.StatusTooManyRequests)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allow reports whether the request is allowed, the request is allowed
+// if the key is empty or the limiter fails.
+func allow(ctx context.Context, l Limiter, key KeyFunc) (Result, bool) {
+	k := key(ctx)
+	if k == "" {
+		return Result{Allowed: true}, true
+	}
+	rs, err := l.Allow(ctx, k)
+	if err != nil {
+		log.Context(ctx).Errorf("ratelimit: check rate limit failed, key: %s, err: %v", k, err)
+		return Result{Allowed: true}, true
+	}
+	return rs, rs.Allowed
+}
+
+func exhausted(rs Result) error {
+	return status.ResourceExhausted("rate limit exceeded, retry after %s", rs.RetryAfter.Round(time.Millisecond))
+}
+
+// retryAfter return retry after in seconds, rounded up.
+func retryAfter(rs Result) string {
+	return strconv.Itoa(int(math.Ceil(rs.RetryAfter.Seconds())))
+}
+
+// httpContext return context of the request with headers as incoming metadata
+// and remote address as peer so that the KeyFuncs can be used for both gRPC and HTTP.
+func httpContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	md := metadata.MD{}
+	if imd, ok := metadata.FromIncomingContext(ctx); ok {
+		md = imd.Copy()
+	}
+	for k, v := range r.Header {
+		k = strings.ToLower(k)
+		if _, ok := md[k]; !ok {
+			md[k] = v
+		}
+	}
+	ctx = metadata.NewIncomingContext(ctx, md)
+	if _, ok := peer.FromContext(ctx); !ok {
+		if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,81 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/pthethanh/micro/auth/jwt"
+	"github.com/pthethanh/micro/ratelimit"
+	"github.com/pthethanh/micro/ratelimit/memory"
+)
+
+func TestKeyFunc(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "1.2.3.4, 10.0.0.2", ratelimit.APIKeyMD, "key1"))
+	ctx = jwt.NewContext(ctx, jwt.Claims{})
+	cases := []struct {
+		name string
+		f    ratelimit.KeyFunc
+		want string
+	}{
+		{name: "ip", f: ratelimit.ByIP(0), want: "ip:10.0.0.1"},
+		{name: "ip forwarded", f: ratelimit.ByIP(1), want: "ip:10.0.0.2"},
+		{name: "ip forwarded 2 hops", f: ratelimit.ByIP(2), want: "ip:1.2.3.4"},
+		{name: "ip not enough hops", f: ratelimit.ByIP(3), want: "ip:10.0.0.1"},
+		{name: "api key", f: ratelimit.ByAPIKey(), want: "key:key1"},
+		{name: "empty subject", f: ratelimit.BySubject(), want: ""},
+		{name: "first of", f: ratelimit.FirstOf(ratelimit.BySubject(), ratelimit.ByAPIKey()), want: "key:key1"},
+	}
+	for _, c := range cases {
+		if got := c.f(ctx); got != c.want {
+			t.Errorf("%s: got key=%s, want key=%s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	l := ratelimit.TokenBucket(memory.New(), 1, time.Minute, 1)
+	f := ratelimit.UnaryInterceptor(l, ratelimit.ByAPIKey())
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Test"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ratelimit.APIKeyMD, "key1"))
+	if _, err := f(ctx, nil, info, handler); err != nil {
+		t.Fatalf("got err=%v, want err=nil", err)
+	}
+	if _, err := f(ctx, nil, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("got code=%v, want code=%v", status.Code(err), codes.ResourceExhausted)
+	}
+	// no key, no limit.
+	if _, err := f(context.Background(), nil, info, handler); err != nil {
+		t.Fatalf("got err=%v, want err=nil", err)
+	}
+}
+
+func TestHTTPInterceptor(t *testing.T) {
+	l := ratelimit.SlidingWindow(memory.New(), 1, time.Minute)
+	h := ratelimit.HTTPInterceptor(l, ratelimit.ByIP(0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != want {
+			t.Fatalf("request %d: got code=%d, want code=%d", i, w.Code, want)
+		}
+		if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Fatalf("got empty Retry-After, want Retry-After set")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/pthethanh/micro/auth/jwt"
+)
+
+type (
+	// KeyFunc return key that identifies the client of the request.
+	// Return empty key to skip rate limiting for the request.
+	KeyFunc func(ctx context.Context) string
+)
+
+const (
+	// APIKeyMD is metadata name of API key.
+	APIKeyMD = "api-key"
+
+	xForwardedFor = "x-forwarded-for"
+)
+
+// ByIP return a KeyFunc that identifies clients by their IP address.
+// If trustedHops is greater than 0, the address appended to X-Forwarded-For by the outermost
+// of the given number of trusted proxies is used, i.e: the trustedHops-th address from the right.
+// The addresses on its left are provided by the clients and never used as they can be spoofed.
+// gRPC Gateway appends the address of the HTTP clients, hence use 1 plus the number of proxies
+// in front of the server for requests via the gateway. If there are not enough addresses,
+// the peer address is used.
+func ByIP(trustedHops int) KeyFunc {
+	return func(ctx context.Context) string {
+		if trustedHops > 0 {
+			if ip := forwardedFor(ctx, trustedHops); ip != "" {
+				return "ip:" + ip
+			}
+		}
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return ""
+		}
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		return "ip:" + host
+	}
+}
+
+// forwardedFor return the hops-th address from the right of X-Forwarded-For,
+// or empty if there are not enough addresses.
+func forwardedFor(ctx context.Context, hops int) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	addrs := make([]string, 0)
+	for _, v := range md.Get(xForwardedFor) {
+		for _, addr := range strings.Split(v, ",") {
+			addrs = append(addrs, strings.TrimSpace(addr))
+		}
+	}
+	if len(addrs) < hops {
+		return ""
+	}
+	return addrs[len(addrs)-hops]
+}
+
+// BySubject return a KeyFunc that identifies clients by subject of their JWT.
+// It requires the JWT claims to be available in the context, see jwt.Authenticator.
+func BySubject() KeyFunc {
+	return func(ctx context.Context) string {
+		claims, ok := jwt.FromContext(ctx)
+		if !ok || claims.Subject == "" {
+			return ""
+		}
+		return "sub:" + claims.Subject
+	}
+}
+
+// ByAPIKey return a KeyFunc that identifies clients by the API key provided in Api-Key header/metadata.
+func ByAPIKey() KeyFunc {
+	return func(ctx context.Context) string {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return ""
+		}
+		if v := md.Get(APIKeyMD); len(v) > 0 && v[0] != "" {
+			return "key:" + v[0]
+		}
+		return ""
+	}
+}
+
+// FirstOf return a KeyFunc that return the first non-empty key of the given KeyFuncs.
+// i.e: FirstOf(BySubject(), ByIP(0)) limits authenticated users by their subject
+// and anonymous users by their IP.
+func FirstOf(fs ...KeyFunc) KeyFunc {
+	return func(ctx context.Context) string {
+		for _, f := range fs {
+			if k := f(ctx); k != "" {
+				return k
+			}
+		}
+		return ""
+	}
+}
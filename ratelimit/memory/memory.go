@@ -0,0 +1,159 @@
+// Package memory provides an in-memory implementation of ratelimit.Store.
+// The states are local to the process, use a shared store such as
+// the Redis store in plugins/ratelimit/redis for cluster-wide limits.
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pthethanh/micro/ratelimit"
+)
+
+type (
+	// Memory is an in-memory implementation of ratelimit.Store.
+	Memory struct {
+		mu        sync.Mutex
+		buckets   map[string]*bucket
+		windows   map[string]*window
+		interval  time.Duration
+		lastSweep time.Time
+	}
+
+	// Option is an option to configure the memory store.
+	Option func(*Memory)
+
+	bucket struct {
+		tokens float64
+		last   time.Time
+		exp    time.Time
+	}
+
+	window struct {
+		start time.Time
+		prev  int
+		cur   int
+		exp   time.Time
+	}
+)
+
+var (
+	_ ratelimit.Store = (*Memory)(nil)
+)
+
+// New return new memory store.
+func New(opts ...Option) *Memory {
+	m := &Memory{
+		buckets:  make(map[string]*bucket),
+		windows:  make(map[string]*window),
+		interval: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// SweepInterval is an option to set the interval for cleaning up idle keys.
+// Default is 1 minute.
+func SweepInterval(d time.Duration) Option {
+	return func(m *Memory) {
+		m.interval = d
+	}
+}
+
+// TokenBucket implements ratelimit.Store.
+func (m *Memory) TokenBucket(ctx context.Context, key string, rate float64, burst int) (ratelimit.Result, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	// the bucket is full again after this duration, it's safe to remove it then.
+	b.exp = now.Add(time.Duration((float64(burst) - b.tokens + 1) / rate * float64(time.Second)))
+	if b.tokens < 1 {
+		return ratelimit.Result{
+			RetryAfter: time.Duration((1 - b.tokens) / rate * float64(time.Second)),
+		}, nil
+	}
+	b.tokens--
+	return ratelimit.Result{
+		Allowed:   true,
+		Remaining: int(b.tokens),
+	}, nil
+}
+
+// SlidingWindow implements ratelimit.Store.
+// It approximates the count in the sliding window using the counts
+// of the current and previous fixed windows.
+func (m *Memory) SlidingWindow(ctx context.Context, key string, limit int, size time.Duration) (ratelimit.Result, error) {
+	now := time.Now()
+	start := now.Truncate(size)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+	w, ok := m.windows[key]
+	if !ok {
+		w = &window{start: start}
+		m.windows[key] = w
+	}
+	if !w.start.Equal(start) {
+		if start.Sub(w.start) == size {
+			w.prev = w.cur
+		} else {
+			w.prev = 0
+		}
+		w.cur = 0
+		w.start = start
+	}
+	w.exp = start.Add(2 * size)
+	elapsed := now.Sub(start)
+	count := float64(w.prev)*(1-float64(elapsed)/float64(size)) + float64(w.cur)
+	if count+1 > float64(limit) {
+		return ratelimit.Result{
+			RetryAfter: slidingRetryAfter(w, limit, size, elapsed),
+		}, nil
+	}
+	w.cur++
+	return ratelimit.Result{
+		Allowed:   true,
+		Remaining: int(float64(limit) - count - 1),
+	}, nil
+}
+
+// slidingRetryAfter return duration until the weighted count of the previous window
+// decreases enough for one more request. If the current window is already full,
+// the current window becomes the previous window of the next one.
+func slidingRetryAfter(w *window, limit int, size time.Duration, elapsed time.Duration) time.Duration {
+	free := float64(limit - 1 - w.cur)
+	if free >= 0 {
+		return time.Duration(float64(size)*(1-free/float64(w.prev))) - elapsed
+	}
+	return size - elapsed + time.Duration(float64(size)*(1-float64(limit-1)/float64(w.cur)))
+}
+
+// sweep removes idle keys, must be called with lock held.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < m.interval {
+		return
+	}
+	m.lastSweep = now
+	for k, b := range m.buckets {
+		if now.After(b.exp) {
+			delete(m.buckets, k)
+		}
+	}
+	for k, w := range m.windows {
+		if now.After(w.exp) {
+			delete(m.windows, k)
+		}
+	}
+}
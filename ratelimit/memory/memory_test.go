@@ -0,0 +1,65 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/ratelimit"
+	"github.com/pthethanh/micro/ratelimit/memory"
+)
+
+func TestTokenBucket(t *testing.T) {
+	l := ratelimit.TokenBucket(memory.New(), 10, 100*time.Millisecond, 2)
+	for i := 0; i < 2; i++ {
+		if rs, err := l.Allow(context.Background(), "k"); err != nil || !rs.Allowed || rs.Remaining != 1-i {
+			t.Fatalf("got allowed=%v, remaining=%d, err=%v, want allowed=true, remaining=%d", rs.Allowed, rs.Remaining, err, 1-i)
+		}
+	}
+	rs, err := l.Allow(context.Background(), "k")
+	if err != nil || rs.Allowed || rs.RetryAfter <= 0 || rs.RetryAfter > 10*time.Millisecond {
+		t.Fatalf("got allowed=%v, retry after=%v, err=%v, want rejected with retry after <= 10ms", rs.Allowed, rs.RetryAfter, err)
+	}
+	if rs, _ := l.Allow(context.Background(), "other"); !rs.Allowed {
+		t.Fatalf("got other key rejected, want allowed")
+	}
+	time.Sleep(rs.RetryAfter + time.Millisecond)
+	if rs, _ := l.Allow(context.Background(), "k"); !rs.Allowed {
+		t.Fatalf("got rejected after refilled, want allowed")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	window := 200 * time.Millisecond
+	l := ratelimit.SlidingWindow(memory.New(), 3, window)
+	// start at beginning of a window so that the test is not affected by the previous window.
+	time.Sleep(time.Until(time.Now().Truncate(window).Add(window)))
+	for i := 0; i < 3; i++ {
+		if rs, err := l.Allow(context.Background(), "k"); err != nil || !rs.Allowed || rs.Remaining != 2-i {
+			t.Fatalf("got allowed=%v, remaining=%d, err=%v, want allowed=true, remaining=%d", rs.Allowed, rs.Remaining, err, 2-i)
+		}
+	}
+	rs, err := l.Allow(context.Background(), "k")
+	if err != nil || rs.Allowed || rs.RetryAfter <= 0 || rs.RetryAfter > 2*window {
+		t.Fatalf("got allowed=%v, retry after=%v, err=%v, want rejected with retry after <= %v", rs.Allowed, rs.RetryAfter, err, 2*window)
+	}
+	time.Sleep(rs.RetryAfter + time.Millisecond)
+	// weighted count of the previous window must have decreased enough for one more request.
+	if rs, _ := l.Allow(context.Background(), "k"); !rs.Allowed {
+		t.Fatalf("got rejected after retry after, want allowed")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	m := memory.New(memory.SweepInterval(time.Millisecond))
+	l := ratelimit.TokenBucket(m, 1, 10*time.Millisecond, 1)
+	if rs, _ := l.Allow(context.Background(), "k"); !rs.Allowed {
+		t.Fatalf("got rejected, want allowed")
+	}
+	time.Sleep(30 * time.Millisecond)
+	// trigger sweep, the idle key must be removed and start with a full bucket.
+	l.Allow(context.Background(), "other")
+	if rs, _ := l.Allow(context.Background(), "k"); !rs.Allowed || rs.Remaining != 0 {
+		t.Fatalf("got allowed=%v, remaining=%d, want allowed=true, remaining=0", rs.Allowed, rs.Remaining)
+	}
+}
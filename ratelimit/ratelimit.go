@@ -0,0 +1,72 @@
+// Package ratelimit provides rate limiters and interceptors for limiting
+// the rate of requests per client using token bucket or sliding window algorithms.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Result is result of a rate limit check.
+	Result struct {
+		// Allowed reports whether the request is allowed.
+		Allowed bool
+		// Remaining is number of requests remaining in the current period.
+		Remaining int
+		// RetryAfter is the duration the client should wait before retrying
+		// if the request is not allowed.
+		RetryAfter time.Duration
+	}
+
+	// Limiter limits rate of requests identified by keys.
+	Limiter interface {
+		// Allow reports whether a request identified by the given key is allowed.
+		Allow(ctx context.Context, key string) (Result, error)
+	}
+
+	// LimiterFunc is a quick way to define a Limiter.
+	LimiterFunc func(ctx context.Context, key string) (Result, error)
+
+	// Store holds states of the rate limit algorithms.
+	// Implementations must update the states atomically so that
+	// a store can be shared between multiple instances for cluster-wide limits.
+	Store interface {
+		// TokenBucket takes a token from the bucket of the key.
+		// The bucket holds maximum burst tokens and is refilled at the given rate per second.
+		TokenBucket(ctx context.Context, key string, rate float64, burst int) (Result, error)
+		// SlidingWindow counts the request in a sliding window of the key,
+		// the request is allowed if the count doesn't exceed the limit.
+		SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (Result, error)
+	}
+)
+
+var (
+	_ Limiter = (LimiterFunc)(nil)
+)
+
+// Allow implements Limiter interface.
+func (f LimiterFunc) Allow(ctx context.Context, key string) (Result, error) {
+	return f(ctx, key)
+}
+
+// TokenBucket return a limiter using token bucket algorithm which allows
+// limit requests per the given period in average with bursts of up to burst requests.
+// If burst <= 0, limit is used as burst.
+func TokenBucket(store Store, limit int, per time.Duration, burst int) Limiter {
+	if burst <= 0 {
+		burst = limit
+	}
+	rate := float64(limit) / per.Seconds()
+	return LimiterFunc(func(ctx context.Context, key string) (Result, error) {
+		return store.TokenBucket(ctx, "tb:"+key, rate, burst)
+	})
+}
+
+// SlidingWindow return a limiter using sliding window algorithm which allows
+// up to limit requests in any window of the given duration.
+func SlidingWindow(store Store, limit int, window time.Duration) Limiter {
+	return LimiterFunc(func(ctx context.Context, key string) (Result, error) {
+		return store.SlidingWindow(ctx, "sw:"+key, limit, window)
+	})
+}
@@ -0,0 +1,119 @@
+// Package dns provides a registry resolving service instances using DNS SRV records,
+// i.e: _grpc._tcp.orders.svc.cluster.local. Registration is managed by the DNS provider,
+// Register and Deregister return registry.ErrNotSupported.
+package dns
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pthethanh/micro/registry"
+)
+
+type (
+	// DNS is an implementation of registry.Registry using DNS SRV records.
+	DNS struct {
+		service  string
+		proto    string
+		domain   string
+		interval time.Duration
+		resolver *net.Resolver
+	}
+
+	// Option is an option to configure the DNS registry.
+	Option func(*DNS)
+)
+
+var (
+	_ registry.Registry = (*DNS)(nil)
+)
+
+// New return new DNS registry. By default, SRV records of _grpc._tcp.<name> are looked up.
+func New(opts ...Option) *DNS {
+	d := &DNS{
+		service:  "grpc",
+		proto:    "tcp",
+		interval: 30 * time.Second,
+		resolver: net.DefaultResolver,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Service is an option to set service and proto of the SRV records. Default is grpc and tcp.
+// Use empty service and proto to look up the name directly.
+func Service(service, proto string) Option {
+	return func(d *DNS) {
+		d.service = service
+		d.proto = proto
+	}
+}
+
+// Domain is an option to set domain appended to the service names, i.e: svc.cluster.local.
+func Domain(domain string) Option {
+	return func(d *DNS) {
+		d.domain = domain
+	}
+}
+
+// Interval is an option to set the interval for looking up when watching. Default is 30s.
+func Interval(interval time.Duration) Option {
+	return func(d *DNS) {
+		d.interval = interval
+	}
+}
+
+// Resolver is an option to set a custom DNS resolver.
+func Resolver(r *net.Resolver) Option {
+	return func(d *DNS) {
+		d.resolver = r
+	}
+}
+
+// Register is not supported.
+func (d *DNS) Register(ctx context.Context, svc registry.Service) error {
+	return registry.ErrNotSupported
+}
+
+// Deregister is not supported.
+func (d *DNS) Deregister(ctx context.Context, svc registry.Service) error {
+	return registry.ErrNotSupported
+}
+
+// Lookup implements registry.Registry.
+func (d *DNS) Lookup(ctx context.Context, name string) ([]registry.Service, error) {
+	host := name
+	if d.domain != "" {
+		host = name + "." + d.domain
+	}
+	_, srvs, err := d.resolver.LookupSRV(ctx, d.service, d.proto, host)
+	if err != nil {
+		return nil, err
+	}
+	svcs := make([]registry.Service, 0, len(srvs))
+	for _, srv := range srvs {
+		addr := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+		svcs = append(svcs, registry.Service{
+			Name:    name,
+			ID:      addr,
+			Address: addr,
+			Metadata: map[string]string{
+				"priority": strconv.Itoa(int(srv.Priority)),
+				"weight":   strconv.Itoa(int(srv.Weight)),
+			},
+		})
+	}
+	return svcs, nil
+}
+
+// Watch implements registry.Registry.
+func (d *DNS) Watch(ctx context.Context, name string) (<-chan []registry.Service, error) {
+	return registry.Poll(ctx, d.interval, func(ctx context.Context) ([]registry.Service, error) {
+		return d.Lookup(ctx, name)
+	}), nil
+}
@@ -0,0 +1,94 @@
+// Package memory provides an in-memory registry, mostly used for testing
+// or services running in the same process.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pthethanh/micro/registry"
+)
+
+type (
+	// Memory is an in-memory implementation of registry.Registry.
+	Memory struct {
+		mu       sync.Mutex
+		services map[string]map[string]registry.Service
+		watchers map[string]map[chan []registry.Service]struct{}
+	}
+)
+
+var (
+	_ registry.Registry = (*Memory)(nil)
+)
+
+// New return new memory registry.
+func New() *Memory {
+	return &Memory{
+		services: make(map[string]map[string]registry.Service),
+		watchers: make(map[string]map[chan []registry.Service]struct{}),
+	}
+}
+
+// Register implements registry.Registry.
+func (m *Memory) Register(ctx context.Context, svc registry.Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.services[svc.Name]; !ok {
+		m.services[svc.Name] = make(map[string]registry.Service)
+	}
+	m.services[svc.Name][svc.ID] = svc
+	m.notify(svc.Name)
+	return nil
+}
+
+// Deregister implements registry.Registry.
+func (m *Memory) Deregister(ctx context.Context, svc registry.Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.services[svc.Name], svc.ID)
+	m.notify(svc.Name)
+	return nil
+}
+
+// Lookup implements registry.Registry.
+func (m *Memory) Lookup(ctx context.Context, name string) ([]registry.Service, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lookup(name), nil
+}
+
+// Watch implements registry.Registry.
+func (m *Memory) Watch(ctx context.Context, name string) (<-chan []registry.Service, error) {
+	ch := make(chan []registry.Service, 1)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.watchers[name]; !ok {
+		m.watchers[name] = make(map[chan []registry.Service]struct{})
+	}
+	m.watchers[name][ch] = struct{}{}
+	registry.Send(ch, m.lookup(name))
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.watchers[name], ch)
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func (m *Memory) lookup(name string) []registry.Service {
+	svcs := make([]registry.Service, 0, len(m.services[name]))
+	for _, svc := range m.services[name] {
+		svcs = append(svcs, svc)
+	}
+	return svcs
+}
+
+// notify sends the services to the watchers, must be called with lock held.
+func (m *Memory) notify(name string) {
+	for ch := range m.watchers[name] {
+		registry.Send(ch, m.lookup(name))
+	}
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pthethanh/micro/log"
+)
+
+// Poll calls lookup every interval and sends the services to the returned channel
+// if they are changed. It's a helper for implementing Watch of the registries
+// that don't support watching natively. The channel is closed when the context is done.
+func Poll(ctx context.Context, interval time.Duration, lookup func(ctx context.Context) ([]Service, error)) <-chan []Service {
+	ch := make(chan []Service, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last []Service
+		first := true
+		for {
+			svcs, err := lookup(ctx)
+			if err != nil {
+				log.Context(ctx).Errorf("registry: lookup failed, err: %v", err)
+			} else if sortServices(svcs); first || !reflect.DeepEqual(svcs, last) {
+				first = false
+				last = svcs
+				Send(ch, svcs)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return ch
+}
+
+// Send sends the services to the given watch channel of buffer size 1,
+// the pending value in the channel is replaced so that the sender is never blocked
+// and the watcher always receives the latest services.
+// Send must not be called concurrently for the same channel.
+func Send(ch chan []Service, svcs []Service) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- svcs
+}
+
+func sortServices(svcs []Service) {
+	sort.Slice(svcs, func(i, j int) bool {
+		if svcs[i].ID != svcs[j].ID {
+			return svcs[i].ID < svcs[j].ID
+		}
+		return svcs[i].Address < svcs[j].Address
+	})
+}
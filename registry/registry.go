@@ -0,0 +1,70 @@
+// Package registry provides service discovery: a registry interface for services to
+// register themselves, implementations of the interface and a gRPC resolver
+// so that clients can dial services by name, i.e: micro:///orders.
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type (
+	// Service is an instance of a service.
+	Service struct {
+		// Name is name of the service, i.e: orders.
+		Name string `json:"name"`
+		// ID is unique id of the instance.
+		ID string `json:"id"`
+		// Address is address of the instance in form of host:port.
+		Address string `json:"address"`
+		// Metadata is additional information of the instance.
+		Metadata map[string]string `json:"metadata,omitempty"`
+	}
+
+	// Registry is a service registry.
+	Registry interface {
+		// Register registers the given service instance.
+		Register(ctx context.Context, svc Service) error
+		// Deregister deregisters the given service instance.
+		Deregister(ctx context.Context, svc Service) error
+		// Lookup return instances of the service of the given name.
+		Lookup(ctx context.Context, name string) ([]Service, error)
+		// Watch watches instances of the service of the given name.
+		// The current instances are sent immediately, then the full list of instances
+		// is sent on every change. The channel is closed when the context is done.
+		Watch(ctx context.Context, name string) (<-chan []Service, error)
+	}
+)
+
+var (
+	// ErrNotSupported indicates that the operation is not supported by the registry,
+	// i.e: registering to DNS. Servers ignore the error when registering themselves.
+	ErrNotSupported = errors.New("registry: operation not supported")
+
+	mu              sync.RWMutex
+	defaultRegistry Registry
+)
+
+// SetDefault sets the default registry used by the micro:/// resolver.
+func SetDefault(r Registry) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultRegistry = r
+}
+
+// Default return the default registry, return nil if it's not set.
+func Default() Registry {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultRegistry
+}
+
+// Addresses return addresses of the given services.
+func Addresses(svcs []Service) []string {
+	addrs := make([]string, 0, len(svcs))
+	for _, svc := range svcs {
+		addrs = append(addrs, svc.Address)
+	}
+	return addrs
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+
+	"github.com/pthethanh/micro/log"
+)
+
+type (
+	resolverBuilder struct {
+		registry Registry
+	}
+
+	serviceResolver struct {
+		cancel context.CancelFunc
+	}
+)
+
+const (
+	// Scheme is scheme of the gRPC resolver, i.e: micro:///orders.
+	Scheme = "micro"
+
+	roundRobinConfig = `{"loadBalancingConfig":[{"round_robin":{}}]}`
+)
+
+func init() {
+	resolver.Register(NewResolverBuilder(nil))
+}
+
+// NewResolverBuilder return a gRPC resolver builder of the micro scheme resolving
+// service names using the given registry, i.e: micro:///orders.
+// If the registry is nil, the default registry is used, see SetDefault.
+// The resolved addresses are balanced using round robin.
+// The builder using the default registry is registered globally, use grpc.WithResolvers
+// to use the builder of a specific registry.
+func NewResolverBuilder(r Registry) resolver.Builder {
+	return &resolverBuilder{registry: r}
+}
+
+// Build implements resolver.Builder.
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	r := b.registry
+	if r == nil {
+		r = Default()
+	}
+	if r == nil {
+		return nil, fmt.Errorf("registry: no registry configured for target %s", target.URL.String())
+	}
+	name := strings.TrimPrefix(target.Endpoint(), "/")
+	if name == "" {
+		return nil, fmt.Errorf("registry: missing service name in target %s", target.URL.String())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := r.Watch(ctx, name)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sc := cc.ParseServiceConfig(roundRobinConfig)
+	go func() {
+		for svcs := range ch {
+			if err := cc.UpdateState(newState(svcs, sc)); err != nil {
+				log.Context(ctx).Errorf("registry: update state of %s failed, err: %v", name, err)
+			}
+		}
+	}()
+	return &serviceResolver{cancel: cancel}, nil
+}
+
+// Scheme implements resolver.Builder.
+func (b *resolverBuilder) Scheme() string {
+	return Scheme
+}
+
+// ResolveNow implements resolver.Resolver.
+// Changes are pushed by the registry, there is nothing to do.
+func (r *serviceResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+// Close implements resolver.Resolver.
+func (r *serviceResolver) Close() {
+	r.cancel()
+}
+
+func newState(svcs []Service, sc *serviceconfig.ParseResult) resolver.State {
+	addrs := make([]resolver.Address, 0, len(svcs))
+	for _, svc := range svcs {
+		addrs = append(addrs, resolver.Address{Addr: svc.Address})
+	}
+	return resolver.State{
+		Addresses:     addrs,
+		ServiceConfig: sc,
+	}
+}
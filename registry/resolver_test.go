@@ -0,0 +1,74 @@
+package registry_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/pthethanh/micro/client"
+	"github.com/pthethanh/micro/registry"
+	"github.com/pthethanh/micro/registry/memory"
+)
+
+func TestResolver(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	r := memory.New()
+	if err := r.Register(context.Background(), registry.Service{Name: "orders", ID: "orders-1", Address: lis.Addr().String()}); err != nil {
+		t.Fatal(err)
+	}
+	for name, opts := range map[string][]grpc.DialOption{
+		"with registry":    {client.WithRegistry(r)},
+		"default registry": {},
+	} {
+		registry.SetDefault(r)
+		conn, err := client.Dial("micro:///orders", append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if _, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+			t.Errorf("%s: got err=%v, want err=nil", name, err)
+		}
+		cancel()
+		conn.Close()
+	}
+}
+
+func TestMemoryWatch(t *testing.T) {
+	r := memory.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := r.Watch(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svcs := <-ch; len(svcs) != 0 {
+		t.Fatalf("got %d services, want 0", len(svcs))
+	}
+	svc := registry.Service{Name: "orders", ID: "1", Address: "localhost:8000"}
+	r.Register(context.Background(), svc)
+	if svcs := <-ch; len(svcs) != 1 || svcs[0].ID != svc.ID {
+		t.Fatalf("got services=%v, want services=[%v]", svcs, svc)
+	}
+	r.Deregister(context.Background(), svc)
+	if svcs := <-ch; len(svcs) != 0 {
+		t.Fatalf("got %d services, want 0", len(svcs))
+	}
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Fatal("got channel opened, want closed after context canceled")
+	}
+}
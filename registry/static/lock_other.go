@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package static
+
+// lock is a no-op on this platform, updates are only serialized within the process.
+func lock(name string) (unlock func() error, err error) {
+	return func() error { return nil }, nil
+}
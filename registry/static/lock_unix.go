@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package static
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lock acquires an exclusive lock of the given lock file, creating it if not exist.
+// The lock is held until unlock is called.
+func lock(name string) (unlock func() error, err error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// closing the file releases the lock.
+	return f.Close, nil
+}
@@ -0,0 +1,171 @@
+// Package static provides a registry backed by a static JSON file mapping service names
+// to their instances, i.e:
+//
+//	{
+//		"orders": [
+//			{"id": "orders-1", "address": "10.0.0.1:8000"},
+//			{"id": "orders-2", "address": "10.0.0.2:8000"}
+//		]
+//	}
+//
+// The file is reloaded periodically for watching. Registering and deregistering
+// update the file, which is useful for running multiple services locally.
+// The updates are serialized across processes by locking the file <file>.lock
+// on Unix-like systems, and within the process only on other systems.
+package static
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pthethanh/micro/registry"
+)
+
+type (
+	// Static is an implementation of registry.Registry backed by a static file.
+	Static struct {
+		file     string
+		interval time.Duration
+		mu       sync.Mutex
+	}
+
+	// Option is an option to configure the static registry.
+	Option func(*Static)
+
+	instance struct {
+		ID       string            `json:"id"`
+		Address  string            `json:"address"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+	}
+)
+
+var (
+	_ registry.Registry = (*Static)(nil)
+)
+
+// New return new static registry backed by the given file.
+func New(file string, opts ...Option) *Static {
+	s := &Static{
+		file:     file,
+		interval: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Interval is an option to set the interval for reloading the file when watching. Default is 5s.
+func Interval(d time.Duration) Option {
+	return func(s *Static) {
+		s.interval = d
+	}
+}
+
+// Register implements registry.Registry.
+func (s *Static) Register(ctx context.Context, svc registry.Service) error {
+	return s.update(func(m map[string][]instance) {
+		list := remove(m[svc.Name], svc.ID)
+		m[svc.Name] = append(list, instance{ID: svc.ID, Address: svc.Address, Metadata: svc.Metadata})
+	})
+}
+
+// Deregister implements registry.Registry.
+func (s *Static) Deregister(ctx context.Context, svc registry.Service) error {
+	return s.update(func(m map[string][]instance) {
+		m[svc.Name] = remove(m[svc.Name], svc.ID)
+		if len(m[svc.Name]) == 0 {
+			delete(m, svc.Name)
+		}
+	})
+}
+
+// Lookup implements registry.Registry.
+func (s *Static) Lookup(ctx context.Context, name string) ([]registry.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	svcs := make([]registry.Service, 0, len(m[name]))
+	for _, i := range m[name] {
+		svcs = append(svcs, registry.Service{Name: name, ID: i.ID, Address: i.Address, Metadata: i.Metadata})
+	}
+	return svcs, nil
+}
+
+// Watch implements registry.Registry.
+func (s *Static) Watch(ctx context.Context, name string) (<-chan []registry.Service, error) {
+	return registry.Poll(ctx, s.interval, func(ctx context.Context) ([]registry.Service, error) {
+		return s.Lookup(ctx, name)
+	}), nil
+}
+
+func (s *Static) update(f func(m map[string][]instance)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// lock a separate file as the file itself is replaced on each update.
+	unlock, err := lock(s.file + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	m, err := s.read()
+	if err != nil {
+		return err
+	}
+	f(m)
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temp file then rename so that readers never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(s.file), filepath.Base(s.file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.file)
+}
+
+// read reads the file, a missing file is treated as an empty registry.
+func (s *Static) read() (map[string][]instance, error) {
+	m := make(map[string][]instance)
+	b, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return m, nil
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func remove(list []instance, id string) []instance {
+	rs := make([]instance, 0, len(list))
+	for _, i := range list {
+		if i.ID != id {
+			rs = append(rs, i)
+		}
+	}
+	return rs
+}
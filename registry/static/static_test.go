@@ -0,0 +1,68 @@
+package static_test
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/registry"
+	"github.com/pthethanh/micro/registry/static"
+)
+
+func TestStatic(t *testing.T) {
+	r := static.New(filepath.Join(t.TempDir(), "registry.json"), static.Interval(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := r.Watch(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svcs := <-ch; len(svcs) != 0 {
+		t.Fatalf("got %d services, want 0", len(svcs))
+	}
+	svcs := []registry.Service{
+		{Name: "orders", ID: "1", Address: "10.0.0.1:8000"},
+		{Name: "orders", ID: "2", Address: "10.0.0.2:8000"},
+		{Name: "users", ID: "1", Address: "10.0.0.3:8000"},
+	}
+	for _, svc := range svcs {
+		if err := r.Register(context.Background(), svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := r.Lookup(context.Background(), "orders"); err != nil || len(got) != 2 {
+		t.Fatalf("got services=%v, err=%v, want 2 services", got, err)
+	}
+	// watcher eventually sees both instances.
+	for got := <-ch; len(got) != 2; got = <-ch {
+	}
+	if err := r.Deregister(context.Background(), svcs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-ch; len(got) != 1 || got[0].ID != "2" {
+		t.Fatalf("got services=%v, want only instance 2", got)
+	}
+}
+
+func TestStaticConcurrentUpdates(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "registry.json")
+	// each registry simulates a process updating the same file.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			svc := registry.Service{Name: "orders", ID: strconv.Itoa(i), Address: "10.0.0.1:8000"}
+			if err := static.New(file).Register(context.Background(), svc); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if got, err := static.New(file).Lookup(context.Background(), "orders"); err != nil || len(got) != 20 {
+		t.Fatalf("got %d services, err=%v, want 20 services", len(got), err)
+	}
+}
@@ -0,0 +1,370 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/pthethanh/micro/status"
+)
+
+type (
+	// Priority is priority class of requests, requests of lower priority
+	// are shed first when the server is overloaded.
+	Priority int
+
+	// LimitAlgorithm calculates concurrency limit from samples of the requests.
+	// The algorithm is called under lock of the limiter, it doesn't need to be thread safe.
+	LimitAlgorithm interface {
+		// Update return new limit given the current limit and a sample of a completed request.
+		// dropped reports whether the request was failed due to overload, i.e: deadline exceeded.
+		Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64
+	}
+
+	// ConcurrencyOption is an option to configure the concurrency limiter.
+	ConcurrencyOption func(*ConcurrencyLimiter)
+
+	// ConcurrencyLimiter limits number of concurrent requests to a limit
+	// which is adapted automatically to the latency and errors of the requests.
+	// Excess requests are rejected with codes.Unavailable or HTTP 503.
+	ConcurrencyLimiter struct {
+		name       string
+		algo       LimitAlgorithm
+		min        float64
+		max        float64
+		priorities map[string]Priority
+
+		mu       sync.Mutex
+		limit    float64
+		inflight int
+
+		limitDesc    *prometheus.Desc
+		inflightDesc *prometheus.Desc
+		rejected     *prometheus.CounterVec
+	}
+
+	statusWriter struct {
+		http.ResponseWriter
+		code int
+	}
+
+	aimd struct {
+		latency time.Duration
+		backoff float64
+	}
+
+	gradient struct {
+		smoothing float64
+		minRTT    time.Duration
+		samples   int
+	}
+)
+
+// Priority classes.
+const (
+	// PriorityLow requests can use up to half of the limit.
+	PriorityLow Priority = iota
+	// PriorityNormal requests can use up to 90% of the limit, it's the default priority.
+	PriorityNormal
+	// PriorityHigh requests can use the whole limit.
+	PriorityHigh
+	// PriorityCritical requests are never rejected but still counted as inflight,
+	// i.e: health checks.
+	PriorityCritical
+)
+
+var (
+	_ prometheus.Collector = (*ConcurrencyLimiter)(nil)
+
+	priorityNames = map[Priority]string{
+		PriorityLow:      "low",
+		PriorityNormal:   "normal",
+		PriorityHigh:     "high",
+		PriorityCritical: "critical",
+	}
+	priorityShares = map[Priority]float64{
+		PriorityLow:    0.5,
+		PriorityNormal: 0.9,
+		PriorityHigh:   1,
+	}
+)
+
+// String implements fmt.Stringer.
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// AIMD return additive increase multiplicative decrease algorithm. The limit is increased by 1
+// on each successful request while the limit is utilized, and is multiplied by backoff (0, 1)
+// if a request is dropped or its latency exceeds the given latency.
+// Use latency <= 0 to only decrease the limit on dropped requests.
+func AIMD(latency time.Duration, backoff float64) LimitAlgorithm {
+	if backoff <= 0 || backoff >= 1 {
+		backoff = 0.9
+	}
+	return &aimd{
+		latency: latency,
+		backoff: backoff,
+	}
+}
+
+// Update implements LimitAlgorithm.
+func (a *aimd) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+	if dropped || (a.latency > 0 && rtt > a.latency) {
+		return limit * a.backoff
+	}
+	if float64(inflight)*2 >= limit {
+		return limit + 1
+	}
+	return limit
+}
+
+// Gradient return gradient based algorithm. The limit is adjusted by the ratio of
+// the minimum latency observed and the latency of the requests, plus a small queue
+// of sqrt(limit) for growing. Smoothing in (0, 1] controls how fast the limit is changed.
+func Gradient(smoothing float64) LimitAlgorithm {
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.2
+	}
+	return &gradient{
+		smoothing: smoothing,
+	}
+}
+
+// Update implements LimitAlgorithm.
+func (g *gradient) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+	if dropped {
+		return limit * 0.5
+	}
+	if rtt <= 0 {
+		return limit
+	}
+	// reset the minimum latency periodically so that the limit can follow
+	// the changes of the latency without load, i.e: slower dependencies.
+	g.samples++
+	if g.minRTT == 0 || rtt < g.minRTT || g.samples > 1000 {
+		g.minRTT = rtt
+		g.samples = 0
+	}
+	// don't grow the limit if it's not utilized.
+	if float64(inflight)*2 < limit {
+		return limit
+	}
+	grad := math.Max(0.5, math.Min(1, float64(g.minRTT)/float64(rtt)))
+	newLimit := limit*grad + math.Sqrt(limit)
+	return limit*(1-g.smoothing) + newLimit*g.smoothing
+}
+
+// NewConcurrencyLimiter return new concurrency limiter.
+// By default, AIMD algorithm is used with initial limit 20, min limit 1, max limit 1000
+// and latency threshold 1s. The gRPC health check method is PriorityCritical by default.
+func NewConcurrencyLimiter(opts ...ConcurrencyOption) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		name:  "default",
+		algo:  AIMD(time.Second, 0.9),
+		min:   1,
+		max:   1000,
+		limit: 20,
+		priorities: map[string]Priority{
+			grpc_health_v1.Health_Check_FullMethodName: PriorityCritical,
+		},
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "server_concurrency_rejected_total",
+			Help: "Total number of requests rejected by the concurrency limiter.",
+		}, []string{"limiter", "priority"}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.limitDesc = prometheus.NewDesc("server_concurrency_limit", "Current concurrency limit.", nil, prometheus.Labels{"limiter": l.name})
+	l.inflightDesc = prometheus.NewDesc("server_concurrency_inflight", "Current number of inflight requests.", nil, prometheus.Labels{"limiter": l.name})
+	return l
+}
+
+// ConcurrencyName is an option to set name of the limiter, used as label of the metrics.
+func ConcurrencyName(name string) ConcurrencyOption {
+	return func(l *ConcurrencyLimiter) {
+		l.name = name
+	}
+}
+
+// ConcurrencyAlgorithm is an option to set algorithm for adapting the limit.
+func ConcurrencyAlgorithm(algo LimitAlgorithm) ConcurrencyOption {
+	return func(l *ConcurrencyLimiter) {
+		l.algo = algo
+	}
+}
+
+// ConcurrencyLimits is an option to set initial, min and max limit.
+func ConcurrencyLimits(initial, min, max int) ConcurrencyOption {
+	return func(l *ConcurrencyLimiter) {
+		l.limit = float64(initial)
+		l.min = float64(min)
+		l.max = float64(max)
+	}
+}
+
+// ConcurrencyPriority is an option to set priority of the requests of the given name.
+// The name is a full gRPC method name (i.e: /helloworld.Greeter/SayHello) or a HTTP path.
+// Name ends with "/" matches all the methods/paths having the name as prefix,
+// i.e: /helloworld.Greeter/ or /api/v1/. The longest matched name wins.
+func ConcurrencyPriority(name string, p Priority) ConcurrencyOption {
+	return func(l *ConcurrencyLimiter) {
+		l.priorities[name] = p
+	}
+}
+
+// Limit return current limit.
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Inflight return current number of inflight requests.
+func (l *ConcurrencyLimiter) Inflight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// Acquire acquires a slot for a request of the given name, return false if the request must be rejected.
+// On success, release must be called when the request completes, with dropped reports
+// whether the request was failed due to overload. Use ignore to release the slot without
+// updating the limit, i.e: for long-lived streams.
+func (l *ConcurrencyLimiter) Acquire(name string) (release func(dropped, ignore bool), ok bool) {
+	p := l.priority(name)
+	l.mu.Lock()
+	if p != PriorityCritical && float64(l.inflight) >= math.Max(1, l.limit*priorityShares[p]) {
+		l.mu.Unlock()
+		l.rejected.WithLabelValues(l.name, p.String()).Inc()
+		return nil, false
+	}
+	l.inflight++
+	l.mu.Unlock()
+	start := time.Now()
+	var once sync.Once
+	return func(dropped, ignore bool) {
+		once.Do(func() {
+			rtt := time.Since(start)
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if !ignore {
+				l.limit = math.Min(l.max, math.Max(l.min, l.algo.Update(l.limit, rtt, l.inflight, dropped)))
+			}
+			l.inflight--
+		})
+	}, true
+}
+
+// UnaryInterceptor return a grpc.UnaryServerInterceptor that rejects excess requests with codes.Unavailable.
+func (l *ConcurrencyLimiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+		release, ok := l.Acquire(info.FullMethod)
+		if !ok {
+			return nil, status.Unavailable("server is overloaded, please retry later")
+		}
+		defer func() {
+			release(isDropped(grpcstatus.Code(err)), false)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor return a grpc.StreamServerInterceptor that rejects excess streams with codes.Unavailable.
+// Streams are counted as inflight but their latency is not used for adapting the limit.
+func (l *ConcurrencyLimiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		release, ok := l.Acquire(info.FullMethod)
+		if !ok {
+			return status.Unavailable("server is overloaded, please retry later")
+		}
+		defer func() {
+			release(isDropped(grpcstatus.Code(err)), true)
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// HTTPInterceptor return a HTTP interceptor that rejects excess requests with HTTP 503.
+// gRPC requests are skipped so that the interceptor can be used along with the gRPC interceptors.
+// Note that requests via gRPC Gateway are limited by the gRPC interceptors already,
+// attach the interceptor to specific handlers via HandlerOptions.Interceptors to avoid counting them twice.
+func (l *ConcurrencyLimiter) HTTPInterceptor() HTTPInterceptor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isGRPCRequest(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			release, ok := l.Acquire(r.URL.Path)
+			if !ok {
+				http.Error(w, "server is overloaded, please retry later", http.StatusServiceUnavailable)
+				return
+			}
+			sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+			defer func() {
+				release(sw.code == http.StatusServiceUnavailable || sw.code == http.StatusGatewayTimeout, false)
+			}()
+			h.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (l *ConcurrencyLimiter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- l.limitDesc
+	ch <- l.inflightDesc
+	l.rejected.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (l *ConcurrencyLimiter) Collect(ch chan<- prometheus.Metric) {
+	l.mu.Lock()
+	limit, inflight := l.limit, l.inflight
+	l.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(l.limitDesc, prometheus.GaugeValue, math.Floor(limit))
+	ch <- prometheus.MustNewConstMetric(l.inflightDesc, prometheus.GaugeValue, float64(inflight))
+	l.rejected.Collect(ch)
+}
+
+func (l *ConcurrencyLimiter) priority(name string) Priority {
+	if p, ok := l.priorities[name]; ok {
+		return p
+	}
+	p, n := PriorityNormal, 0
+	for k, v := range l.priorities {
+		if strings.HasSuffix(k, "/") && strings.HasPrefix(name, k) && len(k) > n {
+			p, n = v, len(k)
+		}
+	}
+	return p
+}
+
+// isDropped reports whether the request was failed due to overload. codes.ResourceExhausted is
+// not counted as it's mostly returned by rate limiters and quota checks rather than overload.
+func isDropped(code codes.Code) bool {
+	return code == codes.DeadlineExceeded || code == codes.Unavailable
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush implements http.Flusher.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestConcurrencyLimiterPriority(t *testing.T) {
+	l := NewConcurrencyLimiter(
+		ConcurrencyLimits(10, 1, 10),
+		ConcurrencyPriority("/low/", PriorityLow),
+		ConcurrencyPriority("/health", PriorityCritical),
+	)
+	releases := make([]func(bool, bool), 0)
+	acquire := func(name string) bool {
+		release, ok := l.Acquire(name)
+		if ok {
+			releases = append(releases, release)
+		}
+		return ok
+	}
+	for i := 0; i < 5; i++ {
+		if !acquire("/low/a") {
+			t.Fatalf("low %d: got rejected, want allowed", i)
+		}
+	}
+	if acquire("/low/b") {
+		t.Fatalf("low: got allowed over half of the limit, want rejected")
+	}
+	for i := 0; i < 4; i++ {
+		if !acquire("/normal") {
+			t.Fatalf("normal %d: got rejected, want allowed", i)
+		}
+	}
+	if acquire("/normal") {
+		t.Fatalf("normal: got allowed over 90%% of the limit, want rejected")
+	}
+	if !acquire("/health") {
+		t.Fatalf("critical: got rejected, want allowed")
+	}
+	if got := l.Inflight(); got != 10 {
+		t.Fatalf("got inflight=%d, want inflight=10", got)
+	}
+	for _, release := range releases {
+		release(false, true)
+	}
+	if got := l.Inflight(); got != 0 {
+		t.Fatalf("got inflight=%d, want inflight=0", got)
+	}
+}
+
+func TestConcurrencyLimiterAIMD(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyLimits(10, 2, 20), ConcurrencyAlgorithm(AIMD(0, 0.5)))
+	release, _ := l.Acquire("/test")
+	release(true, false)
+	if got := l.Limit(); got != 5 {
+		t.Fatalf("got limit=%d, want limit=5 after dropped", got)
+	}
+	rs := make([]func(bool, bool), 0)
+	for i := 0; i < 3; i++ {
+		r, _ := l.Acquire("/test")
+		rs = append(rs, r)
+	}
+	rs[0](false, false)
+	if got := l.Limit(); got != 6 {
+		t.Fatalf("got limit=%d, want limit=6 after success while utilized", got)
+	}
+}
+
+func TestConcurrencyLimiterInterceptors(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyLimits(1, 1, 1))
+	block := make(chan struct{})
+	started := make(chan struct{})
+	interceptor := l.UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Test"}
+	go interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		close(started)
+		<-block
+		return nil, nil
+	})
+	<-started
+	defer close(block)
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("got code=%v, want code=%v", status.Code(err), codes.Unavailable)
+	}
+	h := l.HTTPInterceptor()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got code=%d, want code=%d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestConcurrencyLimiterRelease(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyLimits(10, 1, 10), ConcurrencyAlgorithm(AIMD(0, 0.5)))
+	interceptor := l.UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Test"}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("got no panic, want panic")
+			}
+		}()
+		interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("test")
+		})
+	}()
+	if got := l.Inflight(); got != 0 {
+		t.Fatalf("got inflight=%d after panic, want inflight=0", got)
+	}
+	interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.ResourceExhausted, "rate limited")
+	})
+	if got := l.Limit(); got != 10 {
+		t.Fatalf("got limit=%d after resource exhausted, want limit=10", got)
+	}
+	interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.DeadlineExceeded, "timeout")
+	})
+	if got := l.Limit(); got != 5 {
+		t.Fatalf("got limit=%d after deadline exceeded, want limit=5", got)
+	}
+}
+
+func TestConcurrencyLimiterHealthCheck(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyLimits(1, 1, 1))
+	release, ok := l.Acquire("/test/Test")
+	if !ok {
+		t.Fatalf("got rejected, want allowed")
+	}
+	defer release(false, true)
+	if _, ok := l.Acquire("/test/Test"); ok {
+		t.Fatalf("got allowed over the limit, want rejected")
+	}
+	release, ok = l.Acquire(grpc_health_v1.Health_Check_FullMethodName)
+	if !ok {
+		t.Fatalf("health check: got rejected, want allowed")
+	}
+	release(false, true)
+}
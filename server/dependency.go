@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pthethanh/micro/health"
+)
+
+type (
+	// Dependency is a component the server depends on, i.e: broker.Broker, cache.Cacher.
+	// If the dependency implements health.Checker, it is registered as a health checker
+	// of the default health check server. If it implements Name() string, the name is used
+	// as the health check service name, otherwise its type name is used, i.e: nats.Nats.
+	Dependency interface {
+		// Open establish connection to the target server.
+		Open(ctx context.Context) error
+		// Close close the underlying connection.
+		Close(ctx context.Context) error
+	}
+
+	dependency struct {
+		Dependency
+		name string
+	}
+)
+
+// Dependencies is an option to manage lifecycle of the dependencies of the server.
+// The dependencies are opened in order before the server starts serving and closed
+// in reverse order on shutdown within the shutdown timeout.
+func Dependencies(deps ...Dependency) Option {
+	return func(opts *Server) {
+		for _, d := range deps {
+			opts.dependencies = append(opts.dependencies, dependency{
+				Dependency: d,
+				name:       opts.dependencyName(d),
+			})
+		}
+	}
+}
+
+func (server *Server) dependencyName(d Dependency) string {
+	base := strings.TrimPrefix(fmt.Sprintf("%T", d), "*")
+	if n, ok := d.(interface{ Name() string }); ok {
+		base = n.Name()
+	}
+	exists := func(name string) bool {
+		for _, dep := range server.dependencies {
+			if dep.name == name {
+				return true
+			}
+		}
+		return false
+	}
+	name := base
+	for i := 2; exists(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// dependencyCheckers return health checkers of the dependencies.
+func (server *Server) dependencyCheckers() map[string]health.Checker {
+	checkers := make(map[string]health.Checker)
+	for _, d := range server.dependencies {
+		if c, ok := d.Dependency.(health.Checker); ok {
+			checkers[d.name] = c
+		}
+	}
+	return checkers
+}
+
+// openDependencies opens the dependencies in order. If one of them failed,
+// the opened ones are closed in reverse order.
+func (server *Server) openDependencies(ctx context.Context) error {
+	for i, d := range server.dependencies {
+		if err := d.Open(ctx); err != nil {
+			server.opened = server.dependencies[:i]
+			server.closeDependencies(ctx)
+			return fmt.Errorf("server: open dependency %s, err: %w", d.name, err)
+		}
+		server.log.Context(ctx).Infof("server: dependency %s opened", d.name)
+	}
+	server.opened = server.dependencies
+	return nil
+}
+
+// closeDependencies closes the opened dependencies in reverse order.
+func (server *Server) closeDependencies(ctx context.Context) {
+	for i := len(server.opened) - 1; i >= 0; i-- {
+		d := server.opened[i]
+		if err := d.Close(ctx); err != nil {
+			server.log.Context(ctx).Errorf("server: close dependency %s, err: %v", d.name, err)
+			continue
+		}
+		server.log.Context(ctx).Infof("server: dependency %s closed", d.name)
+	}
+	server.opened = nil
+}
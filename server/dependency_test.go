@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/health"
+)
+
+type testDependency struct {
+	name    string
+	openErr error
+	mu      *sync.Mutex
+	events  *[]string
+}
+
+func (d *testDependency) Name() string { return d.name }
+
+func (d *testDependency) Open(ctx context.Context) error {
+	d.record("open " + d.name)
+	return d.openErr
+}
+
+func (d *testDependency) Close(ctx context.Context) error {
+	d.record("close " + d.name)
+	return nil
+}
+
+func (d *testDependency) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+func (d *testDependency) record(e string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	*d.events = append(*d.events, e)
+}
+
+func TestDependencies(t *testing.T) {
+	mu := &sync.Mutex{}
+	events := []string{}
+	get := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, events...)
+	}
+	newDep := func(name string, err error) *testDependency {
+		return &testDependency{name: name, openErr: err, mu: mu, events: &events}
+	}
+	equal := func(got, want []string) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	t.Run("lifecycle", func(t *testing.T) {
+		events = events[:0]
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := New(Listener(lis), Dependencies(newDep("broker", nil), newDep("cache", nil)))
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- srv.ListenAndServeContext(ctx)
+		}()
+		waitFor(t, func() bool {
+			return healthStatus(lis.Addr().String(), "cache") == http.StatusOK
+		})
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for shutdown")
+		}
+		want := []string{"open broker", "open cache", "close cache", "close broker"}
+		if got := get(); !equal(got, want) {
+			t.Errorf("got events=%v, want events=%v", got, want)
+		}
+	})
+
+	t.Run("open failed", func(t *testing.T) {
+		events = events[:0]
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := New(Listener(lis), Dependencies(newDep("broker", nil), newDep("cache", errors.New("connection refused")), newDep("db", nil)))
+		if err := srv.ListenAndServeContext(context.Background()); err == nil {
+			t.Fatal("got err=nil, want open error")
+		}
+		want := []string{"open broker", "open cache", "close broker"}
+		if got := get(); !equal(got, want) {
+			t.Errorf("got events=%v, want events=%v", got, want)
+		}
+		// the listener must be closed.
+		if conn, err := net.Dial("tcp", lis.Addr().String()); err == nil {
+			conn.Close()
+			t.Error("got listener open, want listener closed")
+		}
+	})
+
+	t.Run("with", func(t *testing.T) {
+		events = events[:0]
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := New(Listener(lis)).With(
+			HealthChecks(map[string]health.Checker{"custom": health.CheckFunc(func(context.Context) error { return nil })}),
+			Dependencies(newDep("cache", nil)),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- srv.ListenAndServeContext(ctx)
+		}()
+		waitFor(t, func() bool {
+			return healthStatus(lis.Addr().String(), "custom") == http.StatusOK && healthStatus(lis.Addr().String(), "cache") == http.StatusOK
+		})
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for shutdown")
+		}
+	})
+}
+
+// healthStatus return HTTP status code of the health check of the given service, 0 if the request failed.
+func healthStatus(addr, service string) int {
+	// no keep-alive so that idle connections don't delay the shutdown.
+	c := &http.Client{Timeout: time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+	res, err := c.Get("http://" + addr + "/internal/health?service=" + service)
+	if err != nil {
+		return 0
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return res.StatusCode
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/pthethanh/micro/cache"
+	"github.com/pthethanh/micro/log"
+)
+
+type (
+	// CacheTTLProvider is implemented by services that declare TTL of cached responses of their methods.
+	// The implementation is generated by protoc-gen-micro for methods annotated with
+	// the (micro.cache.cache) method option defined in cache/cachepb/cache.proto.
+	CacheTTLProvider interface {
+		// CacheTTL return TTL of the given full method name.
+		CacheTTL(fullMethod string) (time.Duration, bool)
+	}
+
+	// GRPCCacheOption is an option to configure the gRPC cache interceptor.
+	GRPCCacheOption func(*grpcCache)
+
+	grpcCache struct {
+		cache  cache.Cacher
+		ttls   map[string]time.Duration
+		prefix string
+		mds    []string
+		types  sync.Map
+	}
+)
+
+const (
+	// NoCacheMD is the metadata key that the callers can send
+	// to by pass the cached responses. The fresh response is still cached.
+	NoCacheMD = "x-no-cache"
+)
+
+// GRPCCacheTTL is an option to set TTL of cached responses for the given full method name,
+// i.e: /helloworld.Greeter/SayHello. It overrides TTL declared via the proto method option.
+// Use 0 to disable caching for the method.
+func GRPCCacheTTL(fullMethod string, ttl time.Duration) GRPCCacheOption {
+	return func(c *grpcCache) {
+		c.ttls[fullMethod] = ttl
+	}
+}
+
+// GRPCCachePrefix is an option to set prefix of the cache keys. Default prefix is "grpc:".
+func GRPCCachePrefix(prefix string) GRPCCacheOption {
+	return func(c *grpcCache) {
+		c.prefix = prefix
+	}
+}
+
+// GRPCCacheMetadata is an option to set metadata keys that are used as a part of the cache key,
+// i.e: authorization if responses are different per user.
+func GRPCCacheMetadata(keys ...string) GRPCCacheOption {
+	return func(c *grpcCache) {
+		c.mds = keys
+	}
+}
+
+// CacheUnaryInterceptor return a grpc.UnaryServerInterceptor that caches responses
+// of the methods that have TTL configured via GRPCCacheTTL option or
+// the (micro.cache.cache) proto method option, using the given cacher.
+// The responses are keyed by full method name and deterministic marshal of the requests.
+// Callers can by pass the cached responses by sending NoCacheMD in the metadata.
+// Only idempotent methods should be cached.
+func CacheUnaryInterceptor(c cache.Cacher, opts ...GRPCCacheOption) grpc.UnaryServerInterceptor {
+	gc := &grpcCache{
+		cache:  c,
+		ttls:   make(map[string]time.Duration),
+		prefix: "grpc:",
+	}
+	for _, opt := range opts {
+		opt(gc)
+	}
+	return gc.intercept
+}
+
+func (gc *grpcCache) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ttl := gc.ttlOf(info)
+	msg, ok := req.(proto.Message)
+	if ttl <= 0 || !ok {
+		return handler(ctx, req)
+	}
+	key, err := gc.key(ctx, info.FullMethod, msg)
+	if err != nil {
+		log.Context(ctx).Errorf("server: grpc cache, build key failed, method: %s, err: %v", info.FullMethod, err)
+		return handler(ctx, req)
+	}
+	if !noCache(ctx) {
+		if res, ok := gc.get(ctx, key, info.FullMethod); ok {
+			return res, nil
+		}
+	}
+	res, err := handler(ctx, req)
+	if err != nil {
+		return res, err
+	}
+	if m, ok := res.(proto.Message); ok {
+		gc.set(ctx, key, m, ttl)
+	}
+	return res, nil
+}
+
+func (gc *grpcCache) ttlOf(info *grpc.UnaryServerInfo) time.Duration {
+	if ttl, ok := gc.ttls[info.FullMethod]; ok {
+		return ttl
+	}
+	if p, ok := info.Server.(CacheTTLProvider); ok {
+		if ttl, ok := p.CacheTTL(info.FullMethod); ok {
+			return ttl
+		}
+	}
+	return 0
+}
+
+func (gc *grpcCache) key(ctx context.Context, method string, req proto.Message) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(b)
+	if len(gc.mds) > 0 {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, k := range gc.mds {
+			fmt.Fprintf(h, "|%s=%s", k, strings.Join(md.Get(k), ","))
+		}
+	}
+	return gc.prefix + method + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (gc *grpcCache) get(ctx context.Context, key string, method string) (proto.Message, bool) {
+	b, err := gc.cache.Get(ctx, key)
+	if err != nil {
+		if err != cache.ErrNotFound {
+			log.Context(ctx).Errorf("server: grpc cache, get failed, key: %s, err: %v", key, err)
+		}
+		return nil, false
+	}
+	mt, err := gc.responseType(method)
+	if err != nil {
+		log.Context(ctx).Errorf("server: grpc cache, resolve response type failed, method: %s, err: %v", method, err)
+		return nil, false
+	}
+	res := mt.New().Interface()
+	if err := proto.Unmarshal(b, res); err != nil {
+		log.Context(ctx).Errorf("server: grpc cache, invalid entry, key: %s, err: %v", key, err)
+		return nil, false
+	}
+	return res, true
+}
+
+func (gc *grpcCache) set(ctx context.Context, key string, res proto.Message, ttl time.Duration) {
+	b, err := proto.Marshal(res)
+	if err != nil {
+		log.Context(ctx).Errorf("server: grpc cache, marshal failed, key: %s, err: %v", key, err)
+		return
+	}
+	if err := gc.cache.Set(ctx, key, b, cache.TTL(ttl)); err != nil {
+		log.Context(ctx).Errorf("server: grpc cache, set failed, key: %s, err: %v", key, err)
+	}
+}
+
+// responseType resolves response type of the given full method name
+// from the global proto registry.
+func (gc *grpcCache) responseType(method string) (protoreflect.MessageType, error) {
+	if mt, ok := gc.types.Load(method); ok {
+		return mt.(protoreflect.MessageType), nil
+	}
+	svc, name, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid method name: %s", method)
+	}
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(svc))
+	if err != nil {
+		return nil, err
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", svc)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("method not found: %s", method)
+	}
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, err
+	}
+	gc.types.Store(method, mt)
+	return mt, nil
+}
+
+func noCache(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	v := md.Get(NoCacheMD)
+	return len(v) > 0 && v[0] != "false" && v[0] != "0"
+}
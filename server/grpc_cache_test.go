@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/cache/memory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+type cacheTTLService struct{}
+
+func (cacheTTLService) CacheTTL(method string) (time.Duration, bool) {
+	if method == "/grpc.health.v1.Health/Check" {
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+func TestCacheUnaryInterceptor(t *testing.T) {
+	c := memory.New()
+	c.Open(context.Background())
+	defer c.Close(context.Background())
+
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+	}
+	check := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check", Server: cacheTTLService{}}
+	watch := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Watch", Server: cacheTTLService{}}
+	noCacheCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(NoCacheMD, "true"))
+	cases := []struct {
+		name  string
+		ctx   context.Context
+		info  *grpc.UnaryServerInfo
+		req   *grpc_health_v1.HealthCheckRequest
+		calls int
+	}{
+		{name: "first call", ctx: context.Background(), info: check, req: &grpc_health_v1.HealthCheckRequest{Service: "a"}, calls: 1},
+		{name: "cached", ctx: context.Background(), info: check, req: &grpc_health_v1.HealthCheckRequest{Service: "a"}, calls: 1},
+		{name: "other request", ctx: context.Background(), info: check, req: &grpc_health_v1.HealthCheckRequest{Service: "b"}, calls: 2},
+		{name: "no cache", ctx: noCacheCtx, info: check, req: &grpc_health_v1.HealthCheckRequest{Service: "a"}, calls: 3},
+		{name: "no ttl", ctx: context.Background(), info: watch, req: &grpc_health_v1.HealthCheckRequest{Service: "a"}, calls: 4},
+		{name: "no ttl again", ctx: context.Background(), info: watch, req: &grpc_health_v1.HealthCheckRequest{Service: "a"}, calls: 5},
+	}
+	interceptor := CacheUnaryInterceptor(c)
+	for _, c := range cases {
+		res, err := interceptor(c.ctx, c.req, c.info, handler)
+		if err != nil {
+			t.Fatalf("%s: got err=%v, want err=nil", c.name, err)
+		}
+		if res.(*grpc_health_v1.HealthCheckResponse).Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Errorf("%s: got res=%v, want status=SERVING", c.name, res)
+		}
+		if calls != c.calls {
+			t.Errorf("%s: got calls=%d, want calls=%d", c.name, calls, c.calls)
+		}
+	}
+
+	// ttl configured in code overrides the provided ttl.
+	interceptor = CacheUnaryInterceptor(c, GRPCCacheTTL(check.FullMethod, 0), GRPCCachePrefix("disabled:"))
+	for i := 0; i < 2; i++ {
+		if _, err := interceptor(context.Background(), &grpc_health_v1.HealthCheckRequest{}, check, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 7 {
+		t.Errorf("got calls=%d, want calls=%d", calls, 7)
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+type (
+	// grpcHTTPHandler serves gRPC requests via grpc.Server.ServeHTTP and tracks them.
+	// The connections hijacked by h2c are not tracked by http.Server.Shutdown,
+	// hence the requests must be completed or canceled before stopping the gRPC server.
+	grpcHTTPHandler struct {
+		srv    *grpc.Server
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		mu     sync.Mutex
+		closed bool
+		wg     sync.WaitGroup
+	}
+)
+
+func newGRPCHTTPHandler(srv *grpc.Server) *grpcHTTPHandler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &grpcHTTPHandler{
+		srv:    srv,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *grpcHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	h.wg.Add(1)
+	h.mu.Unlock()
+	defer h.wg.Done()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stop := context.AfterFunc(h.ctx, cancel)
+	defer stop()
+	h.srv.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// shutdown rejects new requests and waits for the in-flight requests to complete.
+// The remaining requests, i.e: long-lived streams, are canceled when the context is done.
+func (h *grpcHTTPHandler) shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		h.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type (
@@ -20,6 +21,8 @@ type (
 		hdr          []string
 		prefix       bool
 		interceptors []HTTPInterceptor
+		cacheTTL     time.Duration
+		admin        bool
 	}
 
 	handlerOptionsSlice []HandlerOptions
@@ -72,6 +75,20 @@ func (r *HandlerOptions) Interceptors(interceptors ...HTTPInterceptor) *HandlerO
 	return r
 }
 
+// CacheTTL sets TTL of the cached responses of the handler.
+// It requires HTTPCacheInterceptor to be registered via HTTPInterceptors option.
+func (r *HandlerOptions) CacheTTL(ttl time.Duration) *HandlerOptions {
+	r.cacheTTL = ttl
+	return r
+}
+
+// Admin mark that the HTTP handler is an admin handler, which is served on the admin listener
+// if configured via AdminAddress or AdminListener option, otherwise on the main listener.
+func (r *HandlerOptions) Admin() *HandlerOptions {
+	r.admin = true
+	return r
+}
+
 func (p handlerOptionsSlice) Len() int { return len(p) }
 
 func (p handlerOptionsSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
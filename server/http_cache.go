@@ -0,0 +1,422 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pthethanh/micro/cache"
+	"github.com/pthethanh/micro/log"
+)
+
+type (
+	// HTTPCacheOption is an option to configure the HTTP cache interceptor.
+	HTTPCacheOption func(*httpCache)
+
+	httpCache struct {
+		cache   cache.Cacher
+		ttl     time.Duration
+		queries []string
+		headers []string
+		prefix  string
+		maxSize int
+	}
+
+	httpCacheEntry struct {
+		// Vary is set for an entry that only holds the Vary headers of the response.
+		Vary   []string    `json:"vary,omitempty"`
+		Status int         `json:"status,omitempty"`
+		Header http.Header `json:"header,omitempty"`
+		Body   []byte      `json:"body,omitempty"`
+		Time   time.Time   `json:"time"`
+	}
+
+	// httpCacheRoute holds cache info of the matched route.
+	httpCacheRoute struct {
+		ttl time.Duration
+	}
+
+	httpCacheRouteKey struct{}
+
+	cacheResponseWriter struct {
+		http.ResponseWriter
+		status      int
+		buf         bytes.Buffer
+		maxSize     int
+		wroteHeader bool
+		passthrough bool
+	}
+)
+
+const (
+	// XCache is the response header reports whether the response is served from cache: HIT or MISS.
+	XCache = "X-Cache"
+)
+
+// HTTPCacheTTL is an option to set default TTL of the cached responses.
+// If TTL is not set, only responses of the routes configured with HandlerOptions.CacheTTL
+// or responses with Cache-Control max-age are cached.
+func HTTPCacheTTL(ttl time.Duration) HTTPCacheOption {
+	return func(c *httpCache) {
+		c.ttl = ttl
+	}
+}
+
+// HTTPCacheQueries is an option to set query params used as a part of the cache key.
+// By default, all query params are used.
+func HTTPCacheQueries(keys ...string) HTTPCacheOption {
+	return func(c *httpCache) {
+		c.queries = keys
+	}
+}
+
+// HTTPCacheHeaders is an option to set request headers used as a part of the cache key.
+// Headers listed in Vary header of the responses are always used.
+func HTTPCacheHeaders(keys ...string) HTTPCacheOption {
+	return func(c *httpCache) {
+		c.headers = keys
+	}
+}
+
+// HTTPCachePrefix is an option to set prefix of the cache keys. Default prefix is "http:".
+func HTTPCachePrefix(prefix string) HTTPCacheOption {
+	return func(c *httpCache) {
+		c.prefix = prefix
+	}
+}
+
+// HTTPCacheMaxBodySize is an option to set max size of a cacheable response body.
+// Responses with bigger body are streamed to the client without caching.
+// Default size is 1MB.
+func HTTPCacheMaxBodySize(size int) HTTPCacheOption {
+	return func(c *httpCache) {
+		c.maxSize = size
+	}
+}
+
+// HTTPCacheInterceptor return a HTTP interceptor that caches successful responses of GET requests
+// using the given cacher. Responses are keyed by method, path, query params and headers,
+// including headers listed in Vary header of the responses.
+//
+// Cache-Control of requests (no-cache, no-store) and responses (no-store, private, max-age, s-maxage)
+// are honored. An ETag is generated for cached responses if not provided by the handler,
+// and 304 Not Modified is returned if it matches If-None-Match of the request.
+//
+// TTL can be configured per route using HandlerOptions.CacheTTL.
+func HTTPCacheInterceptor(c cache.Cacher, opts ...HTTPCacheOption) HTTPInterceptor {
+	hc := &httpCache{
+		cache:   c,
+		prefix:  "http:",
+		maxSize: 1 << 20,
+	}
+	for _, opt := range opts {
+		opt(hc)
+	}
+	return hc.intercept
+}
+
+func (hc *httpCache) intercept(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.ServeHTTP(w, r)
+			return
+		}
+		reqCC := parseCacheControl(r.Header)
+		if _, ok := reqCC["no-store"]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		key := hc.key(r)
+		_, noCache := reqCC["no-cache"]
+		if reqCC["max-age"] == "0" {
+			noCache = true
+		}
+		if !noCache {
+			if e, ok := hc.get(ctx, key, r); ok {
+				hc.writeEntry(w, r, e, "HIT")
+				return
+			}
+		}
+		route := &httpCacheRoute{}
+		r = r.WithContext(context.WithValue(ctx, httpCacheRouteKey{}, route))
+		cw := &cacheResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+			maxSize:        hc.maxSize,
+		}
+		h.ServeHTTP(cw, r)
+		if cw.passthrough {
+			return
+		}
+		e := &httpCacheEntry{
+			Status: cw.status,
+			Header: w.Header().Clone(),
+			Body:   cw.buf.Bytes(),
+			Time:   time.Now(),
+		}
+		if e.Header.Get("ETag") == "" {
+			sum := sha1.Sum(e.Body)
+			e.Header.Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		}
+		if ttl, ok := hc.ttlOf(r, route, e.Header); ok {
+			hc.set(ctx, key, r, e, ttl)
+		}
+		hc.writeEntry(w, r, e, "MISS")
+	})
+}
+
+// ttlOf return TTL of the response and whether it's cacheable.
+func (hc *httpCache) ttlOf(r *http.Request, route *httpCacheRoute, header http.Header) (time.Duration, bool) {
+	if header.Get("Set-Cookie") != "" || strings.TrimSpace(header.Get("Vary")) == "*" {
+		return 0, false
+	}
+	cc := parseCacheControl(header)
+	for _, d := range []string{"no-store", "private", "no-cache"} {
+		if _, ok := cc[d]; ok {
+			return 0, false
+		}
+	}
+	_, public := cc["public"]
+	_, shared := cc["s-maxage"]
+	// shared caches must not store responses of authorized requests unless explicitly allowed.
+	if r.Header.Get("Authorization") != "" && !public && !shared {
+		return 0, false
+	}
+	for _, d := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[d]; ok {
+			sec, err := strconv.Atoi(v)
+			if err != nil || sec <= 0 {
+				return 0, false
+			}
+			return time.Duration(sec) * time.Second, true
+		}
+	}
+	ttl := hc.ttl
+	if route.ttl != 0 {
+		ttl = route.ttl
+	}
+	return ttl, ttl > 0
+}
+
+func (hc *httpCache) get(ctx context.Context, key string, r *http.Request) (*httpCacheEntry, bool) {
+	e, ok := hc.load(ctx, key)
+	if !ok || len(e.Vary) == 0 {
+		return e, ok
+	}
+	return hc.load(ctx, varyKey(key, e.Vary, r))
+}
+
+func (hc *httpCache) load(ctx context.Context, key string) (*httpCacheEntry, bool) {
+	b, err := hc.cache.Get(ctx, key)
+	if err != nil {
+		if err != cache.ErrNotFound {
+			log.Context(ctx).Errorf("server: http cache, get failed, key: %s, err: %v", key, err)
+		}
+		return nil, false
+	}
+	e := &httpCacheEntry{}
+	if err := json.Unmarshal(b, e); err != nil {
+		log.Context(ctx).Errorf("server: http cache, invalid entry, key: %s, err: %v", key, err)
+		return nil, false
+	}
+	return e, true
+}
+
+func (hc *httpCache) set(ctx context.Context, key string, r *http.Request, e *httpCacheEntry, ttl time.Duration) {
+	if vary := varyHeaders(e.Header); len(vary) > 0 {
+		hc.store(ctx, key, &httpCacheEntry{Vary: vary, Time: e.Time}, ttl)
+		key = varyKey(key, vary, r)
+	}
+	hc.store(ctx, key, e, ttl)
+}
+
+func (hc *httpCache) store(ctx context.Context, key string, e *httpCacheEntry, ttl time.Duration) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.Context(ctx).Errorf("server: http cache, marshal failed, key: %s, err: %v", key, err)
+		return
+	}
+	if err := hc.cache.Set(ctx, key, b, cache.TTL(ttl)); err != nil {
+		log.Context(ctx).Errorf("server: http cache, set failed, key: %s, err: %v", key, err)
+	}
+}
+
+func (hc *httpCache) writeEntry(w http.ResponseWriter, r *http.Request, e *httpCacheEntry, result string) {
+	header := w.Header()
+	for k, v := range e.Header {
+		header[k] = v
+	}
+	header.Set(XCache, result)
+	if result == "HIT" {
+		header.Set("Age", strconv.Itoa(int(time.Since(e.Time).Seconds())))
+	}
+	if etag := e.Header.Get("ETag"); etag != "" && e.Status == http.StatusOK && etagMatch(r.Header.Get("If-None-Match"), etag) {
+		header.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(e.Status)
+	w.Write(e.Body)
+}
+
+func (hc *httpCache) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(hc.prefix)
+	b.WriteString(r.Method)
+	b.WriteString(" ")
+	b.WriteString(r.URL.Path)
+	query := r.URL.Query()
+	if len(hc.queries) > 0 {
+		selected := url.Values{}
+		for _, k := range hc.queries {
+			if v, ok := query[k]; ok {
+				selected[k] = v
+			}
+		}
+		query = selected
+	}
+	if len(query) > 0 {
+		b.WriteString("?")
+		b.WriteString(query.Encode())
+	}
+	writeHeaders(&b, hc.headers, r)
+	return b.String()
+}
+
+func varyKey(key string, vary []string, r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	b.WriteString("|vary")
+	writeHeaders(&b, vary, r)
+	return b.String()
+}
+
+func writeHeaders(b *strings.Builder, keys []string, r *http.Request) {
+	for _, k := range keys {
+		b.WriteString("|")
+		b.WriteString(strings.ToLower(k))
+		b.WriteString("=")
+		b.WriteString(strings.Join(r.Header.Values(k), ","))
+	}
+}
+
+func varyHeaders(header http.Header) []string {
+	vary := make([]string, 0)
+	for _, v := range header.Values("Vary") {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				vary = append(vary, textproto.CanonicalMIMEHeaderKey(k))
+			}
+		}
+	}
+	sort.Strings(vary)
+	return vary
+}
+
+func etagMatch(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(v), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCacheControl parse Cache-Control header into a map of directives and their values.
+func parseCacheControl(header http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, v := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			k, v, _ := strings.Cut(d, "=")
+			cc[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return cc
+}
+
+// withHTTPCacheTTL return an interceptor that set TTL of the route
+// for the HTTP cache interceptor.
+func withHTTPCacheTTL(ttl time.Duration) HTTPInterceptor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route, ok := r.Context().Value(httpCacheRouteKey{}).(*httpCacheRoute); ok {
+				route.ttl = ttl
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteHeader implements http.ResponseWriter.
+// Only successful responses are buffered for caching.
+func (w *cacheResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+	if code != http.StatusOK {
+		w.stream()
+	}
+}
+
+// Write implements http.ResponseWriter.
+func (w *cacheResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.passthrough && w.buf.Len()+len(b) > w.maxSize {
+		w.stream()
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// Flush implements http.Flusher. Flushed responses are considered
+// as streaming responses and are not cached.
+func (w *cacheResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.stream()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// stream switches the writer to pass-through mode,
+// writes the buffered data to the underlying writer and stop caching.
+func (w *cacheResponseWriter) stream() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pthethanh/micro/cache/memory"
+)
+
+func TestHTTPCacheInterceptor(t *testing.T) {
+	c := memory.New()
+	c.Open(context.Background())
+	defer c.Close(context.Background())
+
+	calls := 0
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private")
+		case "/vary":
+			w.Header().Set("Vary", "Accept-Language")
+			w.Write([]byte(r.Header.Get("Accept-Language")))
+			return
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("ok"))
+	})
+	srv := New(HandlerWithOptions("/route", h, NewHandlerOptions().CacheTTL(time.Minute)), PrefixHandler("/", h))
+	router := mux.NewRouter()
+	srv.registerHTTPHandlers(context.Background(), router, router)
+	handler := HTTPCacheInterceptor(c)(router)
+
+	do := func(method, path string, header ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header []string
+		calls  int
+		cache  string
+		code   int
+	}{
+		// no TTL configured, not cached.
+		{name: "no ttl", method: http.MethodGet, path: "/path", calls: 1, cache: "MISS", code: http.StatusOK},
+		{name: "no ttl again", method: http.MethodGet, path: "/path", calls: 2, cache: "MISS", code: http.StatusOK},
+		// route TTL.
+		{name: "route", method: http.MethodGet, path: "/route", calls: 3, cache: "MISS", code: http.StatusOK},
+		{name: "route cached", method: http.MethodGet, path: "/route", calls: 3, cache: "HIT", code: http.StatusOK},
+		{name: "route other query", method: http.MethodGet, path: "/route?a=1", calls: 4, cache: "MISS", code: http.StatusOK},
+		{name: "route no-cache", method: http.MethodGet, path: "/route", header: []string{"Cache-Control", "no-cache"}, calls: 5, cache: "MISS", code: http.StatusOK},
+		{name: "route no-store", method: http.MethodGet, path: "/route", header: []string{"Cache-Control", "no-store"}, calls: 6, code: http.StatusOK},
+		{name: "route post", method: http.MethodPost, path: "/route", calls: 7, code: http.StatusOK},
+		// response Cache-Control.
+		{name: "max-age", method: http.MethodGet, path: "/max-age", calls: 8, cache: "MISS", code: http.StatusOK},
+		{name: "max-age cached", method: http.MethodGet, path: "/max-age", calls: 8, cache: "HIT", code: http.StatusOK},
+		{name: "private", method: http.MethodGet, path: "/private", calls: 9, cache: "MISS", code: http.StatusOK},
+		{name: "private again", method: http.MethodGet, path: "/private", calls: 10, cache: "MISS", code: http.StatusOK},
+		{name: "error", method: http.MethodGet, path: "/error", calls: 11, code: http.StatusInternalServerError},
+		{name: "error again", method: http.MethodGet, path: "/error", calls: 12, code: http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		w := do(c.method, c.path, c.header...)
+		if calls != c.calls {
+			t.Errorf("%s: got calls=%d, want calls=%d", c.name, calls, c.calls)
+		}
+		if got := w.Header().Get(XCache); got != c.cache {
+			t.Errorf("%s: got cache=%s, want cache=%s", c.name, got, c.cache)
+		}
+		if w.Code != c.code {
+			t.Errorf("%s: got code=%d, want code=%d", c.name, w.Code, c.code)
+		}
+	}
+
+	// 304 on matched ETag.
+	w := do(http.MethodGet, "/max-age")
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("got etag=empty, want etag generated")
+	}
+	if w := do(http.MethodGet, "/max-age", "If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("got code=%d, body=%s, want code=%d, body=empty", w.Code, w.Body.String(), http.StatusNotModified)
+	}
+
+	// vary.
+	handler = HTTPCacheInterceptor(c, HTTPCacheTTL(time.Minute))(h)
+	if w := do(http.MethodGet, "/vary", "Accept-Language", "en"); w.Body.String() != "en" {
+		t.Errorf("got body=%s, want body=en", w.Body.String())
+	}
+	if w := do(http.MethodGet, "/vary", "Accept-Language", "vi"); w.Body.String() != "vi" || w.Header().Get(XCache) != "MISS" {
+		t.Errorf("got body=%s, cache=%s, want body=vi, cache=MISS", w.Body.String(), w.Header().Get(XCache))
+	}
+	if w := do(http.MethodGet, "/vary", "Accept-Language", "en"); w.Body.String() != "en" || w.Header().Get(XCache) != "HIT" {
+		t.Errorf("got body=%s, cache=%s, want body=en, cache=HIT", w.Body.String(), w.Header().Get(XCache))
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	// inProcessAddress is the target the gRPC Gateway dials to reach the in-process gRPC server.
+	inProcessAddress = "passthrough:///micro.inprocess"
+
+	inProcessBufferSize = 256 * 1024
+
+	// peerCertificateKey is the metadata key the gRPC Gateway uses to forward
+	// the verified client certificate of the HTTP requests to the in-process gRPC server.
+	peerCertificateKey = "x-micro-peer-certificate"
+)
+
+// serveInProcess serves the gRPC server over an in-memory connection and return
+// the dial options the gRPC Gateway uses to reach it. The gateway never leaves the
+// process, hence it doesn't depend on the listening address or the TLS trust config.
+// The connection is closed by closeInProcess.
+func (server *Server) serveInProcess(grpcServer http.Handler) []grpc.DialOption {
+	lis := bufconn.Listen(inProcessBufferSize)
+	// serve via grpc.Server.ServeHTTP so that the credentials of the gRPC server are not applied.
+	server.inProcessSrv = &http.Server{
+		Handler: h2c.NewHandler(withPeerIdentity(grpcServer), &http2.Server{}),
+	}
+	go server.inProcessSrv.Serve(lis)
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithChainUnaryInterceptor(peerUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(peerStreamInterceptor),
+	}
+}
+
+// closeInProcess closes the in-process connection of the gRPC Gateway.
+func (server *Server) closeInProcess() {
+	if server.inProcessSrv == nil {
+		return
+	}
+	if err := server.inProcessSrv.Close(); err != nil {
+		server.log.Errorf("server: close in-process connection, err: %v", err)
+	}
+}
+
+// withPeerIdentity presents the verified client certificate forwarded by the gRPC Gateway
+// as the verified peer certificate of the in-process requests, so that authenticators relying
+// on client certificates, i.e: mtls.Authenticator, identify the original HTTP client.
+// Requests without a forwarded certificate have no peer certificate.
+func withPeerIdentity(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := r.Header.Get(peerCertificateKey)
+		r.Header.Del(peerCertificateKey)
+		if cert, err := decodePeerCertificate(v); err == nil {
+			r.TLS = &tls.ConnectionState{
+				HandshakeComplete: true,
+				PeerCertificates:  []*x509.Certificate{cert},
+				VerifiedChains:    [][]*x509.Certificate{{cert}},
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// forwardPeerIdentity return a copy of the outgoing context of the gRPC Gateway carrying
+// the verified client certificate of the HTTP request, if any. Values of the key set by
+// the HTTP clients via the gateway metadata are always dropped.
+func forwardPeerIdentity(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Delete(peerCertificateKey)
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 && len(info.State.VerifiedChains[0]) > 0 {
+			md.Set(peerCertificateKey, base64.StdEncoding.EncodeToString(info.State.VerifiedChains[0][0].Raw))
+		}
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func decodePeerCertificate(v string) (*x509.Certificate, error) {
+	if v == "" {
+		return nil, errors.New("server: no peer certificate")
+	}
+	der, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+func peerUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(forwardPeerIdentity(ctx), method, req, reply, cc, opts...)
+}
+
+func peerStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(forwardPeerIdentity(ctx), desc, cc, method, opts...)
+}
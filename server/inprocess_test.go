@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/auth/mtls"
+	pb "github.com/pthethanh/micro/examples/helloworld/helloworld"
+)
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	return &pb.HelloReply{Message: "Hello " + req.Name}, nil
+}
+
+func TestGatewayInProcess(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server")
+	clientCertFile, clientKeyFile := ca.issue(t, dir, "web")
+	clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the address is the default :8000 which is not the listener, the gateway must not dial it.
+	// gateway requests are authenticated by the client certificates of the HTTP requests.
+	srv := New(Listener(lis), TLS(keyFile, certFile), ClientCA(ca.file, false), Auth(mtls.Authenticator(mtls.Allow("web", "server"))))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.ListenAndServeContext(ctx, &greeter{})
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Timeout:   2 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certs}},
+		}
+	}
+	url := "https://" + lis.Addr().String() + "/api/v1/hello"
+	var res *http.Response
+	waitFor(t, func() bool {
+		res, err = newClient(clientCert).Post(url, "application/json", strings.NewReader(`{"name":"micro"}`))
+		return err == nil
+	})
+	defer res.Body.Close()
+	b, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(b), "Hello micro") {
+		t.Errorf("got status=%d, body=%s, want status=200, body contains Hello micro", res.StatusCode, b)
+	}
+
+	// anonymous clients must not get the identity of the server, even if they forge the metadata.
+	serverCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"name":"micro"}`))
+	req.Header.Set("Grpc-Metadata-"+peerCertificateKey, base64.StdEncoding.EncodeToString(serverCert.Certificate[0]))
+	res, err = newClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status=%d, want status=%d", res.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+)
+
+// listenSeparately opens the admin and gRPC listeners if their addresses are configured.
+func (server *Server) listenSeparately() error {
+	if server.adminLis == nil && server.adminAddress != "" {
+		lis, err := net.Listen("tcp", server.adminAddress)
+		if err != nil {
+			return err
+		}
+		server.adminLis = lis
+	}
+	if server.grpcLis == nil && server.grpcAddress != "" {
+		lis, err := net.Listen("tcp", server.grpcAddress)
+		if err != nil {
+			server.closeListeners()
+			return err
+		}
+		server.grpcLis = lis
+	}
+	return nil
+}
+
+// serveSeparately serves the admin handlers and native gRPC on their own listeners if configured.
+// Serving errors are sent to errChan.
+func (server *Server) serveSeparately(grpcServer *grpc.Server, adminRouter *mux.Router, errChan chan<- error) {
+	if server.adminLis != nil {
+		server.adminSrv = &http.Server{
+			Handler:      adminRouter,
+			ReadTimeout:  server.readTimeout,
+			WriteTimeout: server.writeTimeout,
+		}
+		go func() {
+			if err := server.adminSrv.Serve(server.adminLis); err != nil && err != http.ErrServerClosed {
+				errChan <- err
+			}
+		}()
+		server.log.Infof("server: admin listening at: %s", server.adminLis.Addr())
+	}
+	if server.grpcLis != nil {
+		go func() {
+			if err := grpcServer.Serve(server.grpcLis); err != nil {
+				errChan <- err
+			}
+		}()
+		server.log.Infof("server: gRPC listening at: %s", server.grpcLis.Addr())
+	}
+}
+
+// shutdownAdmin shutdowns the admin server gracefully.
+func (server *Server) shutdownAdmin(ctx context.Context) {
+	if server.adminSrv == nil {
+		return
+	}
+	if err := server.adminSrv.Shutdown(ctx); err != nil {
+		server.log.Errorf("server: shutdown admin server error: %v", err)
+	}
+}
+
+func (server *Server) closeListeners() {
+	for _, lis := range []net.Listener{server.adminLis, server.grpcLis} {
+		if lis != nil {
+			lis.Close()
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestSeparateListeners(t *testing.T) {
+	listen := func() net.Listener {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lis
+	}
+	lis, adminLis, grpcLis := listen(), listen(), listen()
+	srv := New(Listener(lis), AdminListener(adminLis), GRPCListener(grpcLis), PProf(""))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.ListenAndServeContext(ctx, &greeter{})
+
+	c := &http.Client{Timeout: 2 * time.Second}
+	get := func(lis net.Listener, path string) int {
+		res, err := c.Get("http://" + lis.Addr().String() + path)
+		if err != nil {
+			return 0
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	waitFor(t, func() bool { return get(adminLis, "/internal/health") == http.StatusOK })
+	if code := get(adminLis, "/debug/pprof/"); code != http.StatusOK {
+		t.Errorf("got admin pprof status=%d, want status=200", code)
+	}
+	if code := get(lis, "/internal/health"); code != http.StatusNotFound {
+		t.Errorf("got main health status=%d, want status=404", code)
+	}
+	res, err := c.Post("http://"+lis.Addr().String()+"/api/v1/hello", "application/json", strings.NewReader(`{"name":"micro"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got gateway status=%d, want status=200", res.StatusCode)
+	}
+
+	conn, err := grpc.Dial(grpcLis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rctx, rcancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer rcancel()
+	if _, err := grpc_health_v1.NewHealthClient(conn).Check(rctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Errorf("got gRPC err=%v, want err=nil", err)
+	}
+}
+
+func TestSeparateListenersListenFailed(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	srv := New(Listener(lis), AdminAddress(busy.Addr().String()))
+	if err := srv.ListenAndServe(); err == nil {
+		t.Fatalf("got err=nil, want listen error")
+	}
+	if conn, err := net.Dial("tcp", lis.Addr().String()); err == nil {
+		conn.Close()
+		t.Fatalf("got main listener open after listen failed, want closed")
+	}
+}
@@ -25,6 +25,9 @@ import (
 	"github.com/pthethanh/micro/config/envconfig"
 	"github.com/pthethanh/micro/health"
 	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/ratelimit"
+	"github.com/pthethanh/micro/registry"
+	"github.com/pthethanh/micro/tracing"
 )
 
 const (
@@ -39,10 +42,21 @@ type (
 		// Address is the address of the service in form of host:port.
 		// If PORT environment variable is configured, it will be prioritized over ADDRESS.
 		Address string `envconfig:"ADDRESS" default:":8000"`
+		// AdminAddress is address of the admin listener serving metrics, health check and pprof
+		// endpoints. If empty, they are served on Address.
+		AdminAddress string `envconfig:"ADMIN_ADDRESS"`
+		// GRPCAddress is address of the native gRPC listener. If empty, gRPC is served on Address.
+		GRPCAddress string `envconfig:"GRPC_ADDRESS"`
 		// TLSCertFile is path to the TLS certificate file.
 		TLSCertFile string `envconfig:"TLS_CERT_FILE"`
 		// TLSKeyFile is the path to the TLS key file.
 		TLSKeyFile string `envconfig:"TLS_KEY_FILE"`
+		// TLSClientCAFile is path to the CA file for verifying client certificates (mutual TLS).
+		TLSClientCAFile string `envconfig:"TLS_CLIENT_CA_FILE"`
+		// TLSClientCertOptional allows clients without certificate when mutual TLS is enabled.
+		TLSClientCertOptional bool `envconfig:"TLS_CLIENT_CERT_OPTIONAL" default:"false"`
+		// TLSReloadInterval is interval for checking changes of the TLS files, 0 to disable reloading.
+		TLSReloadInterval time.Duration `envconfig:"TLS_RELOAD_INTERVAL" default:"30s"`
 
 		// HealthCheckPath is API path for the health check.
 		HealthCheckPath string `envconfig:"HEALTH_CHECK_PATH" default:"/internal/health"`
@@ -53,6 +67,10 @@ type (
 		WriteTimeout time.Duration `envconfig:"WRITE_TIMEOUT" default:"30s"`
 		//ShutdownTimeout is timeout for shutting down the server.
 		ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
+		// DrainPeriod is duration the server keeps serving after the health status is set to NOT_SERVING on shutdown.
+		DrainPeriod time.Duration `envconfig:"DRAIN_PERIOD" default:"0s"`
+		// ShutdownHooksTimeout is timeout for running the shutdown hooks.
+		ShutdownHooksTimeout time.Duration `envconfig:"SHUTDOWN_HOOKS_TIMEOUT" default:"10s"`
 		// APIPrefix is path prefix that gRPC API Gateway is routed to.
 		APIPrefix string `envconfig:"API_PREFIX" default:"/api/"`
 
@@ -115,13 +133,25 @@ func FromConfig(conf Config) Option {
 			StreamInterceptors(CorrelationIDStreamInterceptor()),
 			Address(conf.Address),
 			TLS(conf.TLSKeyFile, conf.TLSCertFile),
+			TLSReloadInterval(conf.TLSReloadInterval),
 			Timeout(conf.ReadTimeout, conf.WriteTimeout),
 			JWT(conf.JWTSecret),
 			APIPrefix(conf.APIPrefix),
 			CORS(conf.CORSAllowedCredential, conf.CORSAllowedHeaders, conf.CORSAllowedMethods, conf.CORSAllowedOrigins),
 			ShutdownTimeout(conf.ShutdownTimeout),
+			DrainPeriod(conf.DrainPeriod),
+			ShutdownHooksTimeout(conf.ShutdownHooksTimeout),
 			RoutesPrioritization(conf.RoutesPrioritization),
 		}
+		if conf.AdminAddress != "" {
+			opts = append(opts, AdminAddress(conf.AdminAddress))
+		}
+		if conf.GRPCAddress != "" {
+			opts = append(opts, GRPCAddress(conf.GRPCAddress))
+		}
+		if conf.TLSClientCAFile != "" {
+			opts = append(opts, ClientCA(conf.TLSClientCAFile, !conf.TLSClientCertOptional))
+		}
 		if conf.Metrics {
 			opts = append(opts, Metrics(conf.MetricsPath))
 		}
@@ -177,6 +207,43 @@ func Listener(lis net.Listener) Option {
 	}
 }
 
+// AdminAddress is an option to serve the admin endpoints: metrics, health check, pprof and
+// the handlers marked via HandlerOptions.Admin on a separate listener at the given address,
+// i.e: a port that is not exposed by the ingress. The admin endpoints are served via plain
+// HTTP without the HTTP interceptors. By default, they are served on the main listener.
+func AdminAddress(addr string) Option {
+	return func(opts *Server) {
+		opts.adminAddress = addr
+	}
+}
+
+// AdminListener is an option similar to AdminAddress, but serves the admin endpoints
+// on an existing listener.
+func AdminListener(lis net.Listener) Option {
+	return func(opts *Server) {
+		opts.adminAddress = lis.Addr().String()
+		opts.adminLis = lis
+	}
+}
+
+// GRPCAddress is an option to serve native gRPC on a separate listener at the given address.
+// The main listener then serves HTTP and gRPC Gateway only. TLS is applied if enabled.
+// By default, gRPC and HTTP are served on the same listener.
+func GRPCAddress(addr string) Option {
+	return func(opts *Server) {
+		opts.grpcAddress = addr
+	}
+}
+
+// GRPCListener is an option similar to GRPCAddress, but serves native gRPC
+// on an existing listener.
+func GRPCListener(lis net.Listener) Option {
+	return func(opts *Server) {
+		opts.grpcAddress = lis.Addr().String()
+		opts.grpcLis = lis
+	}
+}
+
 // StreamInterceptors is an option allows user to add additional stream interceptors to the server.
 func StreamInterceptors(interceptors ...grpc.StreamServerInterceptor) Option {
 	return func(opts *Server) {
@@ -209,6 +276,54 @@ func Auth(f auth.Authenticator) Option {
 	}
 }
 
+// RateLimit is an option to limit rate of gRPC requests, including the requests via gRPC Gateway,
+// per client identified by the given key func using the given limiter.
+// Rate limited requests are rejected with codes.ResourceExhausted, or HTTP 429
+// via gRPC Gateway, along with Retry-After header.
+// The limiter is applied after authentication so that ratelimit.BySubject can be used.
+// Note that gRPC Gateway appends the client address to X-Forwarded-For,
+// use ratelimit.ByIP(1) to limit them by IP, or ratelimit.ByIP(1+n) if there
+// are n trusted proxies in front of the server.
+// For other HTTP handlers, use ratelimit.HTTPInterceptor via HTTPInterceptors option.
+func RateLimit(l ratelimit.Limiter, key ratelimit.KeyFunc) Option {
+	return func(opts *Server) {
+		opts.rateLimiter = l
+		opts.rateLimitKey = key
+	}
+}
+
+// ConcurrencyLimit is an option to limit number of concurrent gRPC requests, including the requests
+// via gRPC Gateway, using the given limiter. Excess requests are rejected with codes.Unavailable,
+// or HTTP 503 via gRPC Gateway. Metrics of the limiter are registered if metrics are enabled.
+// For other HTTP handlers, use ConcurrencyLimiter.HTTPInterceptor.
+func ConcurrencyLimit(l *ConcurrencyLimiter) Option {
+	return func(opts *Server) {
+		opts.concurrencyLimiter = l
+		opts.streamInterceptors = append(opts.streamInterceptors, l.StreamInterceptor())
+		opts.unaryInterceptors = append(opts.unaryInterceptors, l.UnaryInterceptor())
+	}
+}
+
+// ServiceRegistry is an option to register the server to the given registry as an instance
+// of the service of the given name on startup and deregister it on shutdown,
+// so that clients can dial the service by name, i.e: client.Dial("micro:///orders").
+// See AdvertiseAddress for the registered address.
+func ServiceRegistry(r registry.Registry, name string) Option {
+	return func(opts *Server) {
+		opts.registry = r
+		opts.serviceName = name
+	}
+}
+
+// AdvertiseAddress is an option to set the address registered to the service registry.
+// By default, the address is resolved from the listener address and the network interfaces,
+// the native gRPC listener is used if configured, see GRPCAddress.
+func AdvertiseAddress(addr string) Option {
+	return func(opts *Server) {
+		opts.advertiseAddress = addr
+	}
+}
+
 // Logger is an option allows user to add a custom logger into the server.
 func Logger(logger log.Logger) Option {
 	return func(opts *Server) {
@@ -234,6 +349,29 @@ func TLS(key, cert string) Option {
 	}
 }
 
+// ClientCA is an option to enable mutual TLS, client certificates are verified using the CAs
+// in the given file. If required is false, clients without certificate are still accepted.
+// It has no effect if TLS is not enabled. Use mtls.Authenticator via Auth option
+// to authenticate the clients and access their identity. The verified client certificates
+// of the requests via gRPC Gateway are forwarded to the gRPC server.
+func ClientCA(caFile string, required bool) Option {
+	return func(opts *Server) {
+		opts.clientCAFile = caFile
+		opts.clientCertRequired = required
+	}
+}
+
+// TLSReloadInterval is an option to set the interval for checking changes of the TLS certificate
+// and key files. The certificate is reloaded without restarting the server when the files are changed,
+// i.e: rotated by a sidecar. If the files become invalid, the current certificate is kept and
+// status of TLSHealthServiceName in the health check server is set to NOT_SERVING.
+// Default is 30s, use 0 to disable reloading.
+func TLSReloadInterval(d time.Duration) Option {
+	return func(opts *Server) {
+		opts.tlsReloadInterval = d
+	}
+}
+
 // Timeout is an option to override default read/write timeout.
 func Timeout(read, write time.Duration) Option {
 	if read == 0 {
@@ -264,6 +402,8 @@ func Options(serverOpts ...grpc.ServerOption) Option {
 }
 
 // HealthCheck is an option allows user to provide a custom health check server.
+// Besides the given path, the liveness, readiness and startup probes are served at
+// path/live, path/ready and path/startup.
 // Note: this option override any previous health check options.
 func HealthCheck(path string, srv health.Server) Option {
 	return func(opts *Server) {
@@ -278,8 +418,9 @@ func HealthCheck(path string, srv health.Server) Option {
 func HealthChecks(checkers map[string]health.Checker, opts ...health.ServerOption) Option {
 	return func(srv *Server) {
 		srv.healthCheckPath = srv.getHealthCheckPath()
-		opts = append([]health.ServerOption{health.Logger(srv.getLogger())}, opts...)
-		srv.healthSrv = health.NewServer(checkers, opts...)
+		srv.healthCheckers = checkers
+		srv.healthOptions = opts
+		srv.healthSrv = nil
 	}
 }
 
@@ -432,24 +573,29 @@ func CORS(allowCredential bool, headers, methods, origins []string) Option {
 func PProf(pathPrefix string) Option {
 	return func(opts *Server) {
 		opts.routes = append(opts.routes, HandlerOptions{
-			p: pathPrefix + "/debug/pprof/",
-			h: http.HandlerFunc(pprof.Index),
+			p:     pathPrefix + "/debug/pprof/",
+			h:     http.HandlerFunc(pprof.Index),
+			admin: true,
 		})
 		opts.routes = append(opts.routes, HandlerOptions{
-			p: pathPrefix + "/debug/pprof/cmdline",
-			h: http.HandlerFunc(pprof.Cmdline),
+			p:     pathPrefix + "/debug/pprof/cmdline",
+			h:     http.HandlerFunc(pprof.Cmdline),
+			admin: true,
 		})
 		opts.routes = append(opts.routes, HandlerOptions{
-			p: pathPrefix + "/debug/pprof/profile",
-			h: http.HandlerFunc(pprof.Profile),
+			p:     pathPrefix + "/debug/pprof/profile",
+			h:     http.HandlerFunc(pprof.Profile),
+			admin: true,
 		})
 		opts.routes = append(opts.routes, HandlerOptions{
-			p: pathPrefix + "/debug/pprof/symbol",
-			h: http.HandlerFunc(pprof.Symbol),
+			p:     pathPrefix + "/debug/pprof/symbol",
+			h:     http.HandlerFunc(pprof.Symbol),
+			admin: true,
 		})
 		opts.routes = append(opts.routes, HandlerOptions{
-			p: pathPrefix + "/debug/pprof/trace",
-			h: http.HandlerFunc(pprof.Trace),
+			p:     pathPrefix + "/debug/pprof/trace",
+			h:     http.HandlerFunc(pprof.Trace),
+			admin: true,
 		})
 	}
 }
@@ -465,15 +611,16 @@ func Metrics(path string) Option {
 		}
 		opts.enableMetrics = true
 		opts.routes = append(opts.routes, HandlerOptions{
-			p: p,
-			h: promhttp.Handler(),
-			m: []string{http.MethodGet},
+			p:     p,
+			h:     promhttp.Handler(),
+			m:     []string{http.MethodGet},
+			admin: true,
 		})
 	}
 }
 
 // ShutdownTimeout is an option to override default shutdown timeout of server.
-// Set to -1 for no timeout.
+// Default is 30s. Set to -1 for no timeout.
 func ShutdownTimeout(t time.Duration) Option {
 	return func(opts *Server) {
 		opts.shutdownTimeout = t
@@ -481,9 +628,20 @@ func ShutdownTimeout(t time.Duration) Option {
 }
 
 // DefaultHeaderMatcher is an ServerMuxOption that forward
-// header keys X-Request-Id, X-Correlation-ID, Api-Key to gRPC Context.
+// header keys X-Request-Id, X-Correlation-ID, Api-Key, X-No-Cache to gRPC Context.
 func DefaultHeaderMatcher() runtime.ServeMuxOption {
-	return HeaderMatcher([]string{"X-Request-Id", "X-Correlation-ID", "Api-Key"})
+	return HeaderMatcher([]string{"X-Request-Id", "X-Correlation-ID", "Api-Key", "X-No-Cache"})
+}
+
+// retryAfterHeaderMatcher is a ServeMuxOption that forward retry-after metadata
+// as standard Retry-After header and other metadata as the default of gRPC Gateway.
+func retryAfterHeaderMatcher() runtime.ServeMuxOption {
+	return runtime.WithOutgoingHeaderMatcher(func(key string) (string, bool) {
+		if key == ratelimit.RetryAfterMD {
+			return "Retry-After", true
+		}
+		return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+	})
 }
 
 // HeaderMatcher is an ServeMuxOption for matcher header
@@ -501,17 +659,54 @@ func HeaderMatcher(keys []string) runtime.ServeMuxOption {
 	})
 }
 
+// OpenTelemetry is an option to enable OpenTelemetry tracing and metrics for gRPC requests
+// and HTTP requests, including requests via gRPC Gateway. The span context is extracted from
+// the W3C traceparent header of the incoming requests by default, and the trace_id and span_id
+// are added to the context logger if Logger option is used.
+// Admin endpoints, i.e: health check, metrics and pprof, are not traced
+// even if they are served on the same listener.
+func OpenTelemetry(tracingOpts ...tracing.Option) Option {
+	return func(opts *Server) {
+		opts.enableTracing = true
+		opts.tracingOptions = append(opts.tracingOptions, tracingOpts...)
+	}
+}
+
 // Tracing is an option to enable tracing on unary requests.
+//
+// Deprecated: OpenTracing is deprecated, use OpenTelemetry instead.
 func Tracing(tracer opentracing.Tracer) Option {
 	return UnaryInterceptors(otgrpc.OpenTracingServerInterceptor(tracer))
 }
 
 // StreamTracing is an option to enable tracing on stream requests.
+//
+// Deprecated: OpenTracing is deprecated, use OpenTelemetry instead.
 func StreamTracing(tracer opentracing.Tracer) Option {
 	return StreamInterceptors(otgrpc.OpenTracingStreamServerInterceptor(tracer))
 }
 
-// ShutdownHooks register functions that would be called on shutdown.
+// DrainPeriod is an option to set the duration the server keeps serving on shutdown
+// after the health status is set to NOT_SERVING, so that load balancers, i.e: Kubernetes
+// stop routing new requests to the server before it stops accepting them.
+// The shutdown timeout starts after the drain period. Default is 0, no drain.
+func DrainPeriod(d time.Duration) Option {
+	return func(opts *Server) {
+		opts.drainPeriod = d
+	}
+}
+
+// ShutdownHooksTimeout is an option to set the deadline for running the shutdown hooks,
+// the server doesn't wait for the hooks exceeding the deadline. Default is 10s.
+func ShutdownHooksTimeout(d time.Duration) Option {
+	return func(opts *Server) {
+		opts.shutdownHooksTimeout = d
+	}
+}
+
+// ShutdownHooks register functions that would be called on shutdown,
+// after the server stopped serving and before the dependencies are closed.
+// The hooks are run concurrently within the shutdown hooks timeout.
 func ShutdownHooks(fs ...func()) Option {
 	return func(s *Server) {
 		s.shutdownHooks = fs
@@ -519,6 +714,7 @@ func ShutdownHooks(fs ...func()) Option {
 }
 
 // StartupHooks register functions that would be called once the server started.
+// The hooks are run concurrently, the startup probe fails until all of them are completed.
 func StartupHooks(fs ...func()) Option {
 	return func(s *Server) {
 		s.startupHooks = fs
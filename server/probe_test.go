@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStartupProbe(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	srv := New(Listener(lis), StartupHooks(func() {
+		<-release
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.ListenAndServeContext(ctx)
+
+	c := &http.Client{Timeout: time.Second}
+	get := func(probe string) int {
+		res, err := c.Get("http://" + lis.Addr().String() + "/internal/health/" + probe)
+		if err != nil {
+			return 0
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	waitFor(t, func() bool { return get("ready") == http.StatusOK })
+	if code := get("live"); code != http.StatusOK {
+		t.Errorf("got liveness status_code=%d, want status_code=200", code)
+	}
+	if code := get("startup"); code != http.StatusServiceUnavailable {
+		t.Errorf("got startup status_code=%d, want status_code=503 before startup hooks completed", code)
+	}
+	close(release)
+	waitFor(t, func() bool { return get("startup") == http.StatusOK })
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/pthethanh/micro/registry"
+)
+
+// register registers the server instance to the service registry if configured.
+func (server *Server) register(ctx context.Context) error {
+	if server.registry == nil {
+		return nil
+	}
+	addr, err := server.getAdvertiseAddress()
+	if err != nil {
+		return err
+	}
+	svc := registry.Service{
+		Name:    server.serviceName,
+		ID:      server.serviceName + "-" + uuid.NewString(),
+		Address: addr,
+	}
+	if err := server.registry.Register(ctx, svc); err != nil {
+		if errors.Is(err, registry.ErrNotSupported) {
+			return nil
+		}
+		return err
+	}
+	server.instanceMu.Lock()
+	server.instance = &svc
+	server.instanceMu.Unlock()
+	server.log.Context(ctx).Fields("service", svc.Name, "id", svc.ID, "address", svc.Address).Info("server: registered service")
+	return nil
+}
+
+// deregister deregisters the server instance from the service registry if registered.
+func (server *Server) deregister() {
+	server.instanceMu.Lock()
+	instance := server.instance
+	server.instance = nil
+	server.instanceMu.Unlock()
+	if instance == nil {
+		return
+	}
+	svc := *instance
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.registry.Deregister(ctx, svc); err != nil {
+		server.log.Errorf("server: deregister service %s, id: %s, err: %v", svc.Name, svc.ID, err)
+		return
+	}
+	server.log.Fields("service", svc.Name, "id", svc.ID).Info("server: deregistered service")
+}
+
+// getAdvertiseAddress return address that other services use to reach the server.
+// If not configured, the host is resolved from the listener or the network interfaces.
+// The native gRPC listener is used if configured, see GRPCAddress.
+func (server *Server) getAdvertiseAddress() (string, error) {
+	if server.advertiseAddress != "" {
+		return server.advertiseAddress, nil
+	}
+	lis := server.lis
+	if server.grpcLis != nil {
+		lis = server.grpcLis
+	}
+	host, port, err := net.SplitHostPort(lis.Addr().String())
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+		return net.JoinHostPort(host, port), nil
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return net.JoinHostPort(ipnet.IP.String(), port), nil
+		}
+	}
+	host, err = os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/registry/memory"
+)
+
+func TestServiceRegistry(t *testing.T) {
+	listen := func() net.Listener {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lis
+	}
+	testServiceRegistry := func(t *testing.T, want string, opts ...Option) {
+		r := memory.New()
+		srv := New(append(opts, ServiceRegistry(r, "orders"))...)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			srv.ListenAndServeContext(ctx)
+		}()
+		var svcs []string
+		for i := 0; i < 100 && len(svcs) == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+			rs, _ := r.Lookup(context.Background(), "orders")
+			for _, svc := range rs {
+				svcs = append(svcs, svc.Address)
+			}
+		}
+		if len(svcs) != 1 || svcs[0] != want {
+			t.Fatalf("got addresses=%v, want addresses=[%s]", svcs, want)
+		}
+		defer cancel()
+		// both Shutdown and ListenAndServeContext deregister the instance.
+		srv.Shutdown(context.Background())
+		<-done
+		if rs, _ := r.Lookup(context.Background(), "orders"); len(rs) != 0 {
+			t.Fatalf("got %d services after shutdown, want 0", len(rs))
+		}
+	}
+	t.Run("listener", func(t *testing.T) {
+		lis := listen()
+		testServiceRegistry(t, lis.Addr().String(), Listener(lis))
+	})
+	t.Run("grpc listener", func(t *testing.T) {
+		lis, grpcLis := listen(), listen()
+		testServiceRegistry(t, grpcLis.Addr().String(), Listener(lis), GRPCListener(grpcLis))
+	})
+}
@@ -5,44 +5,68 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pthethanh/micro/auth"
+	cacheinstrument "github.com/pthethanh/micro/cache/instrument"
+	"github.com/pthethanh/micro/client"
 	"github.com/pthethanh/micro/health"
 	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/ratelimit"
+	"github.com/pthethanh/micro/registry"
 	"github.com/pthethanh/micro/status"
+	"github.com/pthethanh/micro/tracing"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type (
 	// Server holds the configuration options for the server instance.
 	Server struct {
-		lis         net.Listener
-		httpSrv     *http.Server
-		address     string
-		tlsCertFile string
-		tlsKeyFile  string
+		lis          net.Listener
+		httpSrv      *http.Server
+		inProcessSrv *http.Server
+		grpcSrv      *grpc.Server
+		grpcHTTP     *grpcHTTPHandler
+		address      string
+		tlsCertFile  string
+		tlsKeyFile   string
+
+		// separate listeners
+		adminAddress string
+		adminLis     net.Listener
+		adminSrv     *http.Server
+		grpcAddress  string
+		grpcLis      net.Listener
+
+		clientCAFile       string
+		clientCertRequired bool
+		tlsReloadInterval  time.Duration
+		certReloader       *certReloader
 
 		// HTTP
 		readTimeout          time.Duration
 		writeTimeout         time.Duration
 		shutdownTimeout      time.Duration
+		shutdownHooksTimeout time.Duration
+		drainPeriod          time.Duration
 		routes               []HandlerOptions
 		apiPrefix            string
 		httpInterceptors     []func(http.Handler) http.Handler
@@ -59,11 +83,33 @@ type (
 		log           log.Logger
 		enableMetrics bool
 
+		// OpenTelemetry
+		enableTracing  bool
+		tracingOptions []tracing.Option
+
 		auth auth.Authenticator
 
+		// rate limit
+		rateLimiter  ratelimit.Limiter
+		rateLimitKey ratelimit.KeyFunc
+
+		concurrencyLimiter *ConcurrencyLimiter
+
+		dependencies []dependency
+		opened       []dependency
+
+		// service registry
+		registry         registry.Registry
+		serviceName      string
+		advertiseAddress string
+		instanceMu       sync.Mutex
+		instance         *registry.Service
+
 		// health checks
 		healthCheckPath string
 		healthSrv       health.Server
+		healthCheckers  map[string]health.Checker
+		healthOptions   []health.ServerOption
 		shutdownHooks   []func()
 		startupHooks    []func()
 	}
@@ -93,6 +139,9 @@ type (
 func New(ops ...Option) *Server {
 	server := &Server{
 		routesPrioritization: true,
+		tlsReloadInterval:    30 * time.Second,
+		shutdownTimeout:      30 * time.Second,
+		shutdownHooksTimeout: 10 * time.Second,
 	}
 	for _, op := range ops {
 		op(server)
@@ -103,11 +152,41 @@ func New(ops ...Option) *Server {
 	if server.address == "" {
 		server.address = defaultAddr
 	}
+	return server
+}
+
+// setupHealthCheck creates the default health check server if no custom health check server
+// is provided, and registers the dependencies as its checkers. It is called on serving
+// so that options applied via With are taken into account.
+func (server *Server) setupHealthCheck() {
 	if server.healthSrv == nil {
-		server.healthSrv = health.NewServer(map[string]health.Checker{})
+		checkers := server.dependencyCheckers()
+		for name, c := range server.healthCheckers {
+			checkers[name] = c
+		}
+		opts := append([]health.ServerOption{health.Logger(server.log)}, server.healthOptions...)
+		server.healthSrv = health.NewServer(checkers, opts...)
+		return
+	}
+	if m, ok := server.healthSrv.(interface {
+		AddChecker(name string, c health.Checker)
+	}); ok {
+		for name, c := range server.dependencyCheckers() {
+			m.AddChecker(name, c)
+		}
+	} else if len(server.dependencies) > 0 {
+		server.log.Warn("server: custom health check server is used, dependencies are not registered as health checkers")
 	}
+}
 
-	return server
+// closeOnError closes the listeners and the in-process connection
+// when the server fails to start serving.
+func (server *Server) closeOnError() {
+	server.closeInProcess()
+	if server.lis != nil {
+		server.lis.Close()
+	}
+	server.closeListeners()
 }
 
 // ListenAndServe call ListenAndServeContext with background context.
@@ -121,6 +200,7 @@ func (server *Server) ListenAndServe(services ...interface{}) error {
 // The server starts with default metrics and health endpoints.
 // If the context is canceled or times out, the gRPC server will attempt a graceful shutdown.
 func (server *Server) ListenAndServeContext(ctx context.Context, services ...interface{}) error {
+	server.setupHealthCheck()
 	if server.lis == nil {
 		lis, err := net.Listen("tcp", server.address)
 		if err != nil {
@@ -128,6 +208,10 @@ func (server *Server) ListenAndServeContext(ctx context.Context, services ...int
 		}
 		server.lis = lis
 	}
+	if err := server.listenSeparately(); err != nil {
+		server.lis.Close()
+		return err
+	}
 	if server.auth != nil {
 		server.streamInterceptors = append(server.streamInterceptors, auth.StreamInterceptor(server.auth))
 		server.unaryInterceptors = append(server.unaryInterceptors, auth.UnaryInterceptor(server.auth))
@@ -136,6 +220,10 @@ func (server *Server) ListenAndServeContext(ctx context.Context, services ...int
 		server.streamInterceptors = append(server.streamInterceptors, grpc_prometheus.StreamServerInterceptor)
 		server.unaryInterceptors = append(server.unaryInterceptors, grpc_prometheus.UnaryServerInterceptor)
 	}
+	if server.rateLimiter != nil {
+		server.streamInterceptors = append(server.streamInterceptors, ratelimit.StreamInterceptor(server.rateLimiter, server.rateLimitKey))
+		server.unaryInterceptors = append(server.unaryInterceptors, ratelimit.UnaryInterceptor(server.rateLimiter, server.rateLimitKey))
+	}
 	isSecured := server.tlsCertFile != "" && server.tlsKeyFile != ""
 
 	// server options
@@ -145,32 +233,40 @@ func (server *Server) ListenAndServeContext(ctx context.Context, services ...int
 	if len(server.unaryInterceptors) > 0 {
 		server.serverOptions = append(server.serverOptions, grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(server.unaryInterceptors...)))
 	}
+	if server.enableTracing {
+		server.serverOptions = append(server.serverOptions, grpc.StatsHandler(tracing.ServerHandler(server.tracingOptions...)))
+	}
+	var tlsConfig *tls.Config
 	if isSecured {
-		creds, err := credentials.NewServerTLSFromFile(server.tlsCertFile, server.tlsKeyFile)
+		conf, err := server.getTLSConfig()
 		if err != nil {
+			server.closeOnError()
 			return err
 		}
-		server.serverOptions = append(server.serverOptions, grpc.Creds(creds))
+		tlsConfig = conf
+		server.serverOptions = append(server.serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 	grpcServer := grpc.NewServer(server.serverOptions...)
+	server.grpcSrv = grpcServer
+	server.grpcHTTP = newGRPCHTTPHandler(grpcServer)
 	muxOpts := server.serveMuxOptions
 	if len(muxOpts) == 0 {
 		muxOpts = []runtime.ServeMuxOption{DefaultHeaderMatcher()}
 	}
+	if server.rateLimiter != nil {
+		// forward retry-after as a standard header, custom matchers can still override it.
+		muxOpts = append([]runtime.ServeMuxOption{retryAfterHeaderMatcher()}, muxOpts...)
+	}
 	gw := runtime.NewServeMux(muxOpts...)
 	router := mux.NewRouter()
 
-	dialOpts := make([]grpc.DialOption, 0)
-	if isSecured {
-		creds, err := credentials.NewClientTLSFromFile(server.tlsCertFile, "")
-		if err != nil {
-			return err
-		}
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(creds))
+	// the gateway reaches the gRPC server in-process.
+	dialOpts := server.serveInProcess(server.grpcHTTP)
+	if server.enableTracing {
+		dialOpts = append(dialOpts, grpc.WithStatsHandler(tracing.ClientHandler(server.tracingOptions...)))
 	}
 	if !isSecured {
 		server.log.Context(ctx).Warn("server: insecure mode is enabled.")
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 	// expose health services via gRPC.
 	services = append(services, server.healthSrv)
@@ -185,66 +281,97 @@ func (server *Server) ListenAndServeContext(ctx context.Context, services ...int
 			c++
 		}
 		if srv, ok := s.(EndpointService); ok {
-			srv.RegisterWithEndpoint(ctx, gw, server.address, dialOpts)
+			srv.RegisterWithEndpoint(ctx, gw, inProcessAddress, dialOpts)
 			c++
 		}
 		if c == 0 {
+			server.closeOnError()
 			return status.InvalidArgument("invalid service registration: %v, service should implement one of the interface: server.Service, server.ServiceDescriptor or server.EndpointService", s)
 		}
 	}
 	// Make sure Prometheus metrics are initialized.
 	if server.enableMetrics {
 		grpc_prometheus.Register(grpcServer)
+		if err := cacheinstrument.DefaultMetrics.Register(prometheus.DefaultRegisterer); err != nil {
+			server.log.Context(ctx).Errorf("server: register cache metrics, err: %v", err)
+		}
+		if err := client.DefaultMetrics.Register(prometheus.DefaultRegisterer); err != nil {
+			server.log.Context(ctx).Errorf("server: register client metrics, err: %v", err)
+		}
+		if c, ok := server.healthSrv.(prometheus.Collector); ok {
+			if err := prometheus.Register(c); err != nil {
+				server.log.Context(ctx).Errorf("server: register health check metrics, err: %v", err)
+			}
+		}
+		if server.concurrencyLimiter != nil {
+			if err := prometheus.Register(server.concurrencyLimiter); err != nil {
+				server.log.Context(ctx).Errorf("server: register concurrency limiter metrics, err: %v", err)
+			}
+		}
 	}
 	// Add internal handlers.
-	server.routes = append([]HandlerOptions{
-		{
-			p: server.getHealthCheckPath(),
-			h: server.healthSrv,
-			m: []string{http.MethodGet},
-		},
-	}, server.routes...)
+	healthRoutes := make([]HandlerOptions, 0)
+	for _, p := range []string{"", "/" + health.LivenessProbe, "/" + health.ReadinessProbe, "/" + health.StartupProbe} {
+		healthRoutes = append(healthRoutes, HandlerOptions{
+			p:     server.getHealthCheckPath() + p,
+			h:     server.healthSrv,
+			m:     []string{http.MethodGet},
+			admin: true,
+		})
+	}
+	server.routes = append(healthRoutes, server.routes...)
 	// Serve gRPC and GW only and only if there is at least one service registered.
 	if len(services) > 0 {
 		server.routes = append(server.routes, HandlerOptions{p: server.getAPIPrefix(), h: gw, prefix: true})
 	}
-	// register all http handlers to the router.
-	server.registerHTTPHandlers(ctx, router)
+	// register all http handlers to the router, admin handlers go to the admin router if configured.
+	adminRouter := router
+	if server.adminLis != nil {
+		adminRouter = mux.NewRouter()
+	}
+	server.registerHTTPHandlers(ctx, router, adminRouter)
 
-	errChan := make(chan error, 1)
+	// open the dependencies before serving.
+	if err := server.openDependencies(ctx); err != nil {
+		server.closeOnError()
+		return err
+	}
+	errChan := make(chan error, 3)
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	handler := grpcHandlerFunc(isSecured, grpcServer, router)
+	var handler http.Handler = router
+	if server.grpcLis == nil {
+		handler = grpcHandlerFunc(isSecured, server.grpcHTTP, handler)
+	}
 	for i := len(server.httpInterceptors) - 1; i >= 0; i-- {
 		handler = server.httpInterceptors[i](handler)
 	}
+	if isSecured {
+		handler = withPeerContext(handler)
+	}
 	server.httpSrv = &http.Server{
 		Addr:         server.address,
 		Handler:      handler,
 		ReadTimeout:  server.readTimeout,
 		WriteTimeout: server.writeTimeout,
+		TLSConfig:    tlsConfig,
 	}
 	// register startup hooks using base context func as a trick...
 	server.httpSrv.BaseContext = func(l net.Listener) context.Context {
 		defer func() {
-			for _, f := range server.startupHooks {
-				go f()
-			}
+			go server.runStartupHooks()
 		}()
 		return context.Background()
 	}
-	// register shutdown hooks...
-	for _, f := range server.shutdownHooks {
-		server.httpSrv.RegisterOnShutdown(f)
-	}
 	go func() {
 		if isSecured {
-			errChan <- server.httpSrv.ServeTLS(server.lis, server.tlsCertFile, server.tlsKeyFile)
+			errChan <- server.httpSrv.ServeTLS(server.lis, "", "")
 			return
 		}
 		errChan <- server.httpSrv.Serve(server.lis)
 	}()
+	server.serveSeparately(grpcServer, adminRouter, errChan)
 
 	// init health check service.
 	if err := server.healthSrv.Init(health.StatusServing); err != nil {
@@ -253,12 +380,27 @@ func (server *Server) ListenAndServeContext(ctx context.Context, services ...int
 		return err
 	}
 	defer server.healthSrv.Close()
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+	server.watchCertificate(watchCtx)
+	if err := server.register(ctx); err != nil {
+		server.log.Context(ctx).Errorf("server: register service, err: %v", err)
+		server.Shutdown(ctx)
+		return err
+	}
+	defer server.deregister()
 	server.log.Context(ctx).Infof("server: listening at: %s", server.address)
 	select {
 	case <-ctx.Done():
 		server.Shutdown(ctx)
 		return ctx.Err()
 	case err := <-errChan:
+		// the dependencies are closed by Shutdown if the server is closed by it.
+		if err != http.ErrServerClosed {
+			ctx, cancel := server.shutdownContext(ctx)
+			defer cancel()
+			server.closeDependencies(ctx)
+		}
 		return err
 	case s := <-sigChan:
 		switch s {
@@ -272,7 +414,7 @@ func (server *Server) ListenAndServeContext(ctx context.Context, services ...int
 
 // grpcHandlerFunc returns an http.Handler that delegates to grpcServer on incoming gRPC
 // connections or otherHandler otherwise.
-func grpcHandlerFunc(isSecured bool, grpcServer *grpc.Server, mux http.Handler) http.Handler {
+func grpcHandlerFunc(isSecured bool, grpcServer http.Handler, mux http.Handler) http.Handler {
 	if isSecured {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if isGRPCRequest(r) {
@@ -292,7 +434,7 @@ func grpcHandlerFunc(isSecured bool, grpcServer *grpc.Server, mux http.Handler)
 	}), &http2.Server{})
 }
 
-func (server Server) getHealthCheckPath() string {
+func (server *Server) getHealthCheckPath() string {
 	if server.healthCheckPath == "" {
 		return "/internal/health"
 	}
@@ -312,27 +454,138 @@ func (server *Server) Address() string {
 	return server.address
 }
 
-func (server Server) getAPIPrefix() string {
+func (server *Server) getAPIPrefix() string {
 	if server.apiPrefix == "" {
 		return "/"
 	}
 	return server.apiPrefix
 }
 
-// Shutdown shutdown the server gracefully.
+// Shutdown shutdown the server gracefully:
+//   - deregister from the service registry and set the health status to NOT_SERVING.
+//   - keep serving during the drain period, see DrainPeriod.
+//   - stop accepting new requests and wait for the in-flight requests within the shutdown timeout.
+//   - run the shutdown hooks within their own deadline, see ShutdownHooksTimeout.
+//   - close the dependencies and the admin server.
 func (server *Server) Shutdown(ctx context.Context) {
+	// deregister first so that clients stop sending new requests.
+	server.deregister()
 	if server.healthSrv != nil {
 		if err := server.healthSrv.Close(); err != nil {
 			server.log.Errorf("server: shutdown health check service error: %v", err)
 		}
 	}
+	server.drain()
+	ctx, cancel := server.shutdownContext(ctx)
+	defer cancel()
+	server.stopServing(ctx)
+	server.runShutdownHooks()
+	// close the dependencies once there is no more in-flight requests.
+	server.closeDependencies(ctx)
+	server.shutdownAdmin(ctx)
+}
+
+// drain keeps serving during the drain period so that the load balancers
+// have time to observe the NOT_SERVING status.
+func (server *Server) drain() {
+	if server.drainPeriod <= 0 || server.httpSrv == nil {
+		return
+	}
+	server.log.Infof("server: draining for %s", server.drainPeriod)
+	time.Sleep(server.drainPeriod)
+}
+
+// stopServing stops the HTTP server and the gRPC server gracefully.
+func (server *Server) stopServing(ctx context.Context) {
+	graceful := true
 	if server.httpSrv != nil {
-		ctx, cancel := context.WithTimeout(ctx, server.shutdownTimeout)
-		defer cancel()
 		if err := server.httpSrv.Shutdown(ctx); err != nil {
 			server.log.Errorf("server: shutdown error: %v", err)
+			graceful = false
 		}
 	}
+	// close after the HTTP server so that in-flight gateway requests are completed.
+	server.closeInProcess()
+	if server.grpcSrv == nil {
+		return
+	}
+	// gRPC requests served via the HTTP server, including the streams on the connections
+	// hijacked by h2c, are not completed by the HTTP server shutdown. Wait for or cancel them.
+	if err := server.grpcHTTP.shutdown(ctx); err != nil {
+		server.log.Errorf("server: gRPC requests via HTTP exceeded shutdown timeout, canceled")
+		graceful = false
+	}
+	// GracefulStop panics on draining the HTTP transports, use it only when native gRPC
+	// is served on its own listener, the HTTP transports are completed at this point.
+	if !graceful || server.grpcLis == nil {
+		server.grpcSrv.Stop()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		server.grpcSrv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		server.log.Errorf("server: gRPC graceful stop exceeded shutdown timeout, stop immediately")
+		server.grpcSrv.Stop()
+	}
+}
+
+// runStartupHooks runs the startup hooks concurrently and marks the health check server
+// as started once all of them are completed.
+func (server *Server) runStartupHooks() {
+	wg := sync.WaitGroup{}
+	for _, f := range server.startupHooks {
+		wg.Add(1)
+		go func(f func()) {
+			defer wg.Done()
+			f()
+		}(f)
+	}
+	wg.Wait()
+	if m, ok := server.healthSrv.(health.StartupMarker); ok {
+		m.MarkStarted()
+	}
+}
+
+// runShutdownHooks runs the shutdown hooks concurrently and waits for them within the shutdown hooks timeout.
+func (server *Server) runShutdownHooks() {
+	if len(server.shutdownHooks) == 0 {
+		return
+	}
+	wg := sync.WaitGroup{}
+	for _, f := range server.shutdownHooks {
+		wg.Add(1)
+		go func(f func()) {
+			defer wg.Done()
+			f()
+		}(f)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(server.shutdownHooksTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		server.log.Errorf("server: shutdown hooks exceeded timeout: %s", server.shutdownHooksTimeout)
+	}
+}
+
+// shutdownContext return a context that is not canceled with its parent,
+// but times out after the shutdown timeout.
+func (server *Server) shutdownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if server.shutdownTimeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, server.shutdownTimeout)
 }
 
 func (server *Server) getLogger() log.Logger {
@@ -342,7 +595,7 @@ func (server *Server) getLogger() log.Logger {
 	return server.log
 }
 
-func (server *Server) registerHTTPHandlers(ctx context.Context, router *mux.Router) {
+func (server *Server) registerHTTPHandlers(ctx context.Context, router *mux.Router, adminRouter *mux.Router) {
 	// Longer patterns take precedence over shorter ones.
 	if server.routesPrioritization {
 		sort.Sort(sort.Reverse(handlerOptionsSlice(server.routes)))
@@ -357,11 +610,23 @@ func (server *Server) registerHTTPHandlers(ctx context.Context, router *mux.Rout
 		for _, interceptor := range r.interceptors {
 			h = interceptor(h)
 		}
+		if r.cacheTTL != 0 {
+			h = withHTTPCacheTTL(r.cacheTTL)(h)
+		}
+		// admin handlers are not traced, i.e: health probes and metrics scrapes.
+		// native gRPC requests are traced by the stats handler.
+		if server.enableTracing && !r.admin {
+			h = tracing.HTTPHandler(server.tracingOptions...)(h)
+		}
+		rt := router
+		if r.admin {
+			rt = adminRouter
+		}
 		if r.prefix {
-			route = router.PathPrefix(r.p).Handler(h)
+			route = rt.PathPrefix(r.p).Handler(h)
 			info = append(info, "path_prefix", r.p)
 		} else {
-			route = router.Path(r.p).Handler(h)
+			route = rt.Path(r.p).Handler(h)
 			info = append(info, "path", r.p)
 		}
 		if r.m != nil {
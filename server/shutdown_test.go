@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestShutdownDrain(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hooked int32
+	drain := 300 * time.Millisecond
+	srv := New(Listener(lis), DrainPeriod(drain), ShutdownHooks(func() {
+		atomic.StoreInt32(&hooked, 1)
+	}, func() {
+		// must not block the shutdown longer than the hooks timeout.
+		time.Sleep(time.Hour)
+	}), ShutdownHooksTimeout(100*time.Millisecond))
+	go srv.ListenAndServeContext(context.Background())
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	check := func() (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.WaitForReady(true))
+		return res.GetStatus(), err
+	}
+	waitFor(t, func() bool {
+		status, err := check()
+		return err == nil && status == grpc_health_v1.HealthCheckResponse_SERVING
+	})
+
+	done := make(chan struct{})
+	begin := time.Now()
+	go func() {
+		srv.Shutdown(context.Background())
+		close(done)
+	}()
+	// still serving during the drain period, but NOT_SERVING.
+	waitFor(t, func() bool {
+		status, err := check()
+		return err == nil && status == grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+	if d := time.Since(begin); d < drain {
+		t.Errorf("got shutdown duration=%s, want duration >= %s", d, drain)
+	}
+	if atomic.LoadInt32(&hooked) != 1 {
+		t.Errorf("shutdown hooks were not called")
+	}
+	if _, err := (&http.Client{Timeout: time.Second}).Get("http://" + lis.Addr().String() + "/internal/health"); err == nil {
+		t.Errorf("got err=nil, want err after shutdown")
+	}
+}
+
+func TestShutdownOpenStream(t *testing.T) {
+	for name, grpcSeparately := range map[string]bool{"same listener": false, "grpc listener": true} {
+		t.Run(name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := []Option{Listener(lis), ShutdownTimeout(200 * time.Millisecond)}
+			addr := lis.Addr().String()
+			if grpcSeparately {
+				grpcLis, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatal(err)
+				}
+				opts = append(opts, GRPCListener(grpcLis))
+				addr = grpcLis.Addr().String()
+			}
+			srv := New(opts...)
+			go srv.ListenAndServeContext(context.Background())
+
+			conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.WaitForReady(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := stream.Recv(); err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				srv.Shutdown(context.Background())
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for shutdown")
+			}
+			// the stream is closed by the shutdown.
+			for {
+				if _, err := stream.Recv(); err != nil {
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/pthethanh/micro/auth/mtls"
+	"github.com/pthethanh/micro/health"
+)
+
+// getTLSConfig return TLS config of the server from the configured files.
+// The certificate is reloaded when the files are changed, see watchCertificate.
+func (server *Server) getTLSConfig() (*tls.Config, error) {
+	status, _ := server.healthSrv.(health.StatusSetter)
+	reloader, err := newCertReloader(server.tlsCertFile, server.tlsKeyFile, server.log, status)
+	if err != nil {
+		return nil, err
+	}
+	server.certReloader = reloader
+	conf := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if server.clientCAFile != "" {
+		pool, err := loadCertPool(server.clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+		if server.clientCertRequired {
+			conf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return conf, nil
+}
+
+// watchCertificate reloads the TLS certificate when the files are changed until the context is done.
+func (server *Server) watchCertificate(ctx context.Context) {
+	if server.certReloader == nil || server.tlsReloadInterval <= 0 {
+		return
+	}
+	go server.certReloader.watch(ctx, server.tlsReloadInterval)
+}
+
+// withPeerContext is a HTTP interceptor that makes TLS information of the HTTP requests
+// available as peer in the context, see mtls.NewPeerContext.
+func withPeerContext(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || isGRPCRequest(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(mtls.NewPeerContext(r)))
+	})
+}
+
+func loadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("server: no valid certificate found in %s", f)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pthethanh/micro/health"
+	"github.com/pthethanh/micro/log"
+)
+
+type (
+	// certReloader watches the certificate and key files and reloads
+	// the certificate when they are changed.
+	certReloader struct {
+		certFile string
+		keyFile  string
+		log      log.Logger
+		status   health.StatusSetter
+
+		mu      sync.RWMutex
+		cert    *tls.Certificate
+		modTime time.Time
+	}
+)
+
+const (
+	// TLSHealthServiceName is name of the service in the health check server
+	// reporting status of the TLS certificate.
+	TLSHealthServiceName = "tls"
+)
+
+func newCertReloader(certFile, keyFile string, logger log.Logger, status health.StatusSetter) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		log:      logger,
+		status:   status,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate return the current certificate, used as tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// watch checks the files every interval and reloads the certificate if they are changed,
+// until the context is done. If the files are invalid, the current certificate is kept
+// and status of the TLS service is set to NOT_SERVING.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		modTime, err := r.lastModified()
+		r.mu.RLock()
+		changed := err != nil || !modTime.Equal(r.modTime)
+		r.mu.RUnlock()
+		if !changed {
+			r.checkExpiry()
+			continue
+		}
+		if err := r.reload(); err != nil {
+			r.log.Context(ctx).Errorf("server: reload TLS certificate failed, keep using the current certificate, err: %v", err)
+			r.setStatus(health.StatusNotServing)
+			continue
+		}
+	}
+}
+
+func (r *certReloader) reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	if time.Now().After(leaf.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", leaf.NotAfter)
+	}
+	cert.Leaf = leaf
+	r.mu.Lock()
+	rotated := r.cert != nil
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+	if rotated {
+		r.log.Fields("subject", leaf.Subject.String(), "serial", leaf.SerialNumber.String(), "not_after", leaf.NotAfter).Info("server: TLS certificate rotated")
+	}
+	r.setStatus(health.StatusServing)
+	return nil
+}
+
+func (r *certReloader) checkExpiry() {
+	r.mu.RLock()
+	leaf := r.cert.Leaf
+	r.mu.RUnlock()
+	if time.Now().After(leaf.NotAfter) {
+		r.log.Errorf("server: TLS certificate expired at %s", leaf.NotAfter)
+		r.setStatus(health.StatusNotServing)
+	}
+}
+
+// lastModified return the latest modification time of the files.
+func (r *certReloader) lastModified() (time.Time, error) {
+	var t time.Time
+	for _, f := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return t, err
+		}
+		if info.ModTime().After(t) {
+			t = info.ModTime()
+		}
+	}
+	return t, nil
+}
+
+func (r *certReloader) setStatus(status health.Status) {
+	if r.status != nil {
+		r.status.SetStatus(TLSHealthServiceName, status)
+	}
+}
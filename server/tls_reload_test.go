@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/health"
+	"github.com/pthethanh/micro/log"
+)
+
+type statusRecorder struct {
+	mu     sync.Mutex
+	status map[string]health.Status
+}
+
+func (r *statusRecorder) SetStatus(name string, status health.Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status[name] = status
+}
+
+func (r *statusRecorder) get(name string) health.Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status[name]
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "server")
+	status := &statusRecorder{status: make(map[string]health.Status)}
+	r, err := newCertReloader(certFile, keyFile, log.Root(), status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := status.get(TLSHealthServiceName); got != health.StatusServing {
+		t.Fatalf("got status=%v, want status=%v", got, health.StatusServing)
+	}
+	serial := func() string {
+		cert, _ := r.GetCertificate(nil)
+		return cert.Leaf.SerialNumber.String()
+	}
+	old := serial()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.watch(ctx, 10*time.Millisecond)
+
+	// rotate the certificate.
+	ca.issue(t, dir, "server")
+	touch(t, time.Now().Add(time.Second), certFile, keyFile)
+	waitFor(t, func() bool { return serial() != old })
+
+	// invalid files must not replace the current certificate.
+	rotated := serial()
+	if err := os.WriteFile(certFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, time.Now().Add(2*time.Second), certFile)
+	waitFor(t, func() bool { return status.get(TLSHealthServiceName) == health.StatusNotServing })
+	if got := serial(); got != rotated {
+		t.Errorf("got serial=%s, want serial=%s", got, rotated)
+	}
+
+	// recover when the files are valid again.
+	ca.issue(t, dir, "server")
+	touch(t, time.Now().Add(3*time.Second), certFile, keyFile)
+	waitFor(t, func() bool { return status.get(TLSHealthServiceName) == health.StatusServing })
+	if got := serial(); got == rotated {
+		t.Errorf("got serial=%s, want a new certificate", got)
+	}
+}
+
+func touch(t *testing.T, mt time.Time, files ...string) {
+	for _, f := range files {
+		if err := os.Chtimes(f, mt, mt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pthethanh/micro/client"
+	pb "github.com/pthethanh/micro/examples/helloworld/helloworld"
+	"github.com/pthethanh/micro/log"
+	"github.com/pthethanh/micro/tracing"
+	"github.com/pthethanh/micro/tracing/tracingtest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestOpenTelemetry(t *testing.T) {
+	rec := tracingtest.NewRecorder()
+	out := &syncBuffer{}
+	logger, err := log.NewLogrus(log.WithLevel(log.LevelInfo), log.WithWriter(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(Listener(lis), Logger(logger), OpenTelemetry(tracing.WithTracerProvider(rec)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.ListenAndServeContext(ctx, &greeter{})
+
+	// admin endpoints are not traced.
+	waitFor(t, func() bool {
+		res, err := http.Get("http://" + lis.Addr().String() + "/internal/health")
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		return res.StatusCode == http.StatusOK
+	})
+
+	// gateway request continues the trace of the traceparent header.
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	url := "http://" + lis.Addr().String() + "/api/v1/hello"
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"name":"micro"}`))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("got status=%d, want status=200", res.StatusCode)
+	}
+	for _, name := range []string{"HTTP POST", "helloworld.Greeter/SayHello"} {
+		span, ok := rec.Span(name)
+		if !ok {
+			t.Fatalf("got no span %s, want span %s", name, name)
+		}
+		if got := span.SpanContext.TraceID().String(); got != traceID {
+			t.Errorf("got %s trace_id=%s, want trace_id=%s", name, got, traceID)
+		}
+	}
+	if span, ok := rec.Span("HTTP GET"); ok {
+		t.Errorf("got span %s of health check, want health check not traced", span.Name)
+	}
+	if !strings.Contains(out.String(), `"trace_id":"`+traceID+`"`) {
+		t.Errorf("got logs=%s, want logs contain trace_id=%s", out.String(), traceID)
+	}
+
+	// native gRPC request.
+	rec.Reset()
+	dctx, dcancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer dcancel()
+	conn, err := client.DialContext(dctx, lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), client.WithOpenTelemetry(tracing.WithTracerProvider(rec)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := pb.NewGreeterClient(conn).SayHello(dctx, &pb.HelloRequest{Name: "micro"}); err != nil {
+		t.Fatal(err)
+	}
+	var clientSpan, serverSpan trace.SpanContext
+	waitFor(t, func() bool {
+		for _, s := range rec.Spans() {
+			switch s.SpanKind {
+			case trace.SpanKindClient:
+				clientSpan = s.SpanContext
+			case trace.SpanKindServer:
+				serverSpan = s.SpanContext
+			}
+		}
+		return clientSpan.IsValid() && serverSpan.IsValid()
+	})
+	if clientSpan.TraceID() != serverSpan.TraceID() {
+		t.Errorf("got server trace_id=%s, want trace_id=%s", serverSpan.TraceID(), clientSpan.TraceID())
+	}
+}
@@ -0,0 +1,143 @@
+// Package tracing provides OpenTelemetry integration for the server, client,
+// broker and cache packages. Span context is propagated across process boundaries
+// using W3C Trace Context (traceparent) and W3C Baggage by default.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/stats"
+)
+
+type (
+	// Options hold the OpenTelemetry providers used for instrumentation.
+	Options struct {
+		TracerProvider trace.TracerProvider
+		MeterProvider  metric.MeterProvider
+		Propagator     propagation.TextMapPropagator
+	}
+
+	// Option is an option to configure the instrumentation.
+	Option func(*Options)
+)
+
+const (
+	// InstrumentationName is the name of the tracers created by this library.
+	InstrumentationName = "github.com/pthethanh/micro"
+)
+
+var (
+	// Propagator is the default propagator: W3C Trace Context and W3C Baggage.
+	Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+)
+
+// WithTracerProvider is an option to set the tracer provider.
+// Default is the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(opts *Options) {
+		opts.TracerProvider = tp
+	}
+}
+
+// WithMeterProvider is an option to set the meter provider used for the gRPC and HTTP metrics.
+// Default is the global meter provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(opts *Options) {
+		opts.MeterProvider = mp
+	}
+}
+
+// WithPropagator is an option to set the propagator. Default is Propagator.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(opts *Options) {
+		opts.Propagator = p
+	}
+}
+
+// NewOptions return new options with the given options applied.
+// Providers which are not set default to the global providers.
+func NewOptions(opts ...Option) Options {
+	o := Options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.TracerProvider == nil {
+		o.TracerProvider = otel.GetTracerProvider()
+	}
+	if o.MeterProvider == nil {
+		o.MeterProvider = otel.GetMeterProvider()
+	}
+	if o.Propagator == nil {
+		o.Propagator = Propagator
+	}
+	return o
+}
+
+// Tracer return the tracer of this library.
+func (opts Options) Tracer() trace.Tracer {
+	return opts.TracerProvider.Tracer(InstrumentationName)
+}
+
+// ServerHandler return a gRPC stats handler that creates spans and records metrics for incoming requests.
+func ServerHandler(opts ...Option) stats.Handler {
+	o := NewOptions(opts...)
+	return otelgrpc.NewServerHandler(
+		otelgrpc.WithTracerProvider(o.TracerProvider),
+		otelgrpc.WithMeterProvider(o.MeterProvider),
+		otelgrpc.WithPropagators(o.Propagator),
+	)
+}
+
+// ClientHandler return a gRPC stats handler that creates spans and records metrics for outgoing requests.
+func ClientHandler(opts ...Option) stats.Handler {
+	o := NewOptions(opts...)
+	return otelgrpc.NewClientHandler(
+		otelgrpc.WithTracerProvider(o.TracerProvider),
+		otelgrpc.WithMeterProvider(o.MeterProvider),
+		otelgrpc.WithPropagators(o.Propagator),
+	)
+}
+
+// HTTPHandler return a middleware that creates spans and records metrics for incoming HTTP requests.
+func HTTPHandler(opts ...Option) func(http.Handler) http.Handler {
+	o := NewOptions(opts...)
+	return func(h http.Handler) http.Handler {
+		return otelhttp.NewHandler(h, "http.server",
+			otelhttp.WithTracerProvider(o.TracerProvider),
+			otelhttp.WithMeterProvider(o.MeterProvider),
+			otelhttp.WithPropagators(o.Propagator),
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return "HTTP " + r.Method
+			}),
+		)
+	}
+}
+
+// Inject injects the span context of the given context into the headers,
+// i.e: headers of a broker message, using the default Propagator.
+func Inject(ctx context.Context, header map[string]string) {
+	Propagator.Inject(ctx, propagation.MapCarrier(header))
+}
+
+// Extract return a copy of the given context with the span context
+// extracted from the headers using the default Propagator.
+func Extract(ctx context.Context, header map[string]string) context.Context {
+	return Propagator.Extract(ctx, propagation.MapCarrier(header))
+}
+
+// IDs return the trace id and span id of the span in the given context.
+// ok is false if there is no valid span in the context.
+func IDs(ctx context.Context) (traceID string, spanID string, ok bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", "", false
+	}
+	return sc.TraceID().String(), sc.SpanID().String(), true
+}
@@ -0,0 +1,31 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pthethanh/micro/tracing"
+	"github.com/pthethanh/micro/tracing/tracingtest"
+)
+
+func TestInjectExtract(t *testing.T) {
+	rec := tracingtest.NewRecorder()
+	ctx, span := rec.Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+
+	header := make(map[string]string)
+	tracing.Inject(ctx, header)
+	if header["traceparent"] == "" {
+		t.Fatalf("got header=%v, want traceparent header", header)
+	}
+	traceID, spanID, ok := tracing.IDs(tracing.Extract(context.Background(), header))
+	if !ok {
+		t.Fatal("got no span context, want span context")
+	}
+	if traceID != span.SpanContext().TraceID().String() || spanID != span.SpanContext().SpanID().String() {
+		t.Errorf("got trace_id=%s, span_id=%s, want trace_id=%s, span_id=%s", traceID, spanID, span.SpanContext().TraceID(), span.SpanContext().SpanID())
+	}
+	if _, _, ok := tracing.IDs(context.Background()); ok {
+		t.Error("got span context, want no span context")
+	}
+}
@@ -0,0 +1,48 @@
+// Package tracingtest provides an in-memory OpenTelemetry tracer provider for testing.
+package tracingtest
+
+import (
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type (
+	// Recorder is a tracer provider that records all ended spans in memory.
+	Recorder struct {
+		*sdktrace.TracerProvider
+		exporter *tracetest.InMemoryExporter
+	}
+)
+
+// NewRecorder return a new recorder which samples all spans
+// and exports them synchronously when they end.
+func NewRecorder() *Recorder {
+	exporter := tracetest.NewInMemoryExporter()
+	return &Recorder{
+		TracerProvider: sdktrace.NewTracerProvider(
+			sdktrace.WithSampler(sdktrace.AlwaysSample()),
+			sdktrace.WithSyncer(exporter),
+		),
+		exporter: exporter,
+	}
+}
+
+// Spans return the ended spans in the order they ended.
+func (r *Recorder) Spans() tracetest.SpanStubs {
+	return r.exporter.GetSpans()
+}
+
+// Span return the first ended span of the given name.
+func (r *Recorder) Span(name string) (tracetest.SpanStub, bool) {
+	for _, s := range r.exporter.GetSpans() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+// Reset clears the recorded spans.
+func (r *Recorder) Reset() {
+	r.exporter.Reset()
+}